@@ -30,7 +30,11 @@ func main() {
 	}
 	defer db.Close()
 
-	srv := server.New(cfg, db, logger)
+	srv, err := server.New(cfg, db, logger)
+	if err != nil {
+		logger.Error("failed to create server", "err", err)
+		panic(err)
+	}
 
 	if err := srv.Run(ctx); err != nil {
 		logger.Error("server exited with error", "err", err)
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE enrichment_jobs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    movie_id CHAR(26) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    run_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    locked_until TIMESTAMP(6) NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    INDEX idx_enrichment_jobs_status_run_at (status, run_at),
+    INDEX idx_enrichment_jobs_movie (movie_id, id),
+    CONSTRAINT fk_enrichment_jobs_movie
+        FOREIGN KEY (movie_id) REFERENCES movies(id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+-- +goose Up
+CREATE INDEX idx_movie_box_office_fetched_at ON movie_box_office (fetched_at);
@@ -0,0 +1,3 @@
+-- +goose Up
+-- Per-field provenance for distributor/budget/mpaRating, e.g. {"budget": "user"}.
+ALTER TABLE movies ADD COLUMN field_sources JSON NULL AFTER mpa_rating;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE box_office_cache (
+    cache_key VARCHAR(512) PRIMARY KEY,
+    response JSON NULL,
+    source VARCHAR(64) NULL,
+    expires_at TIMESTAMP(6) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_box_office_cache_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE movie_box_office_snapshots (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    movie_id CHAR(26) NOT NULL,
+    gross_usd BIGINT NOT NULL,
+    opening_weekend_usa BIGINT,
+    currency VARCHAR(8) NOT NULL,
+    source VARCHAR(64) NOT NULL,
+    last_reported TIMESTAMP(6) NOT NULL,
+    fetched_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_movie_box_office_snapshots_movie (movie_id, last_reported),
+    CONSTRAINT fk_movie_box_office_snapshots_movie
+        FOREIGN KEY (movie_id) REFERENCES movies(id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Seed the history with the figures already on record.
+INSERT INTO movie_box_office_snapshots (movie_id, gross_usd, opening_weekend_usa, currency, source, last_reported, fetched_at)
+SELECT movie_id, gross_usd, opening_weekend_usa, currency, source, last_reported, fetched_at
+FROM movie_box_office;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Box office figures are stored in the currency named by the currency column.
+ALTER TABLE movie_box_office CHANGE COLUMN gross_usd gross BIGINT NOT NULL;
+ALTER TABLE movie_box_office_snapshots CHANGE COLUMN gross_usd gross BIGINT NOT NULL;
+
+-- rate is the number of currency units one US dollar buys on rate_date.
+CREATE TABLE exchange_rates (
+    currency CHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate DECIMAL(24, 10) NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (currency, rate_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
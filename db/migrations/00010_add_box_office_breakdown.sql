@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE movie_box_office
+    ADD COLUMN domestic BIGINT NULL AFTER gross,
+    ADD COLUMN international BIGINT NULL AFTER domestic,
+    ADD COLUMN theater_count INT NULL AFTER opening_weekend_usa,
+    ADD COLUMN weeks_in_release INT NULL AFTER theater_count;
+
+ALTER TABLE movie_box_office_snapshots
+    ADD COLUMN domestic BIGINT NULL AFTER gross,
+    ADD COLUMN international BIGINT NULL AFTER domestic,
+    ADD COLUMN theater_count INT NULL AFTER opening_weekend_usa,
+    ADD COLUMN weeks_in_release INT NULL AFTER theater_count;
@@ -0,0 +1,4 @@
+-- +goose Up
+-- Full-text search over movies. Text columns added later (synopsis, cast)
+-- belong in this index too, and in the store's MATCH clause with it.
+ALTER TABLE movies ADD FULLTEXT INDEX ft_movies_search (title);
@@ -0,0 +1,4 @@
+-- +goose Up
+ALTER TABLE movie_box_office
+    ADD COLUMN refresh_failures INT NOT NULL DEFAULT 0 AFTER fetched_at,
+    ADD COLUMN refresh_after TIMESTAMP(6) NULL AFTER refresh_failures;
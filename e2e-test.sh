@@ -21,6 +21,13 @@ NC='\033[0m' # No Color
 BASE_URL="${BASE_URL:-http://127.0.0.1:8080}"
 TIMEOUT=10
 
+# Movies created by the later stages carry this suffix so the script can be re-run
+RUN_ID=$(date +%s)
+
+# Response headers of the last make_raw_request call
+RESPONSE_HEADERS=$(mktemp)
+trap 'rm -f "$RESPONSE_HEADERS"' EXIT
+
 # Test counters
 TESTS_PASSED=0
 TESTS_FAILED=0
@@ -100,6 +107,55 @@ make_request() {
     return 0
 }
 
+# HTTP request wrapper for calls that need several headers, a non-JSON body or
+# the response headers. Extra arguments are passed to curl as they are; the
+# response headers are saved to $RESPONSE_HEADERS.
+make_raw_request() {
+    local method=$1
+    local url=$2
+    local expected_status=$3
+    shift 3
+    
+    log_info "Making $method request to $url"
+    
+    encoded_url=$(echo "$url" | sed 's/ /%20/g')
+    response=$(curl -s -w "\n%{http_code}" -X "$method" -D "$RESPONSE_HEADERS" "$@" \
+        --connect-timeout $TIMEOUT "$BASE_URL$encoded_url" 2>/dev/null || echo -e "\n000")
+    
+    body=$(echo "$response" | sed '$d')
+    status=$(echo "$response" | tail -n 1)
+    
+    if [[ "$status" == "000" ]]; then
+        log_error "Request failed - service unreachable"
+        return 1
+    fi
+    
+    if [[ "$status" != "$expected_status" ]]; then
+        log_error "Expected status $expected_status, got $status"
+        log_error "Response body: $body"
+        return 1
+    fi
+    
+    echo "$body"
+    return 0
+}
+
+# Print a header of the last make_raw_request response
+response_header() {
+    grep -i "^$1:" "$RESPONSE_HEADERS" | head -n 1 | cut -d' ' -f2- | tr -d '\r'
+}
+
+# Create a movie and print its ID; the body is the POST /movies JSON
+create_movie() {
+    local data=$1
+    local response
+    if response=$(make_request "POST" "/movies" "-H 'Authorization: Bearer $AUTH_TOKEN'" "$data" 201); then
+        echo "$response" | jq -r '.id'
+        return 0
+    fi
+    return 1
+}
+
 # Stage 1: Environment and Health Check
 stage1_env_health_check() {
     echo -e "\n${BLUE}=== STAGE 1: Environment & Health Check ===${NC}"
@@ -485,6 +541,725 @@ stage6_error_handling() {
     fi
 }
 
+# Stage 7: Single Movie Reads
+stage7_single_movie() {
+    echo -e "\n${BLUE}=== STAGE 7: Single Movie Reads ===${NC}"
+    
+    single_title="E2E Single $RUN_ID"
+    log_info "Creating movie '$single_title'..."
+    if ! single_id=$(create_movie "{\"title\":\"$single_title\",\"genre\":\"Drama\",\"releaseDate\":\"2020-01-01\"}"); then
+        log_error "Failed to create movie for single movie reads"
+        return
+    fi
+    
+    log_info "Getting movie '$single_title'..."
+    if response=$(make_raw_request "GET" "/movies/$single_title" 200); then
+        if echo "$response" | jq -e --arg t "$single_title" '.title == $t and has("boxOffice") and has("rating")' >/dev/null; then
+            log_success "Single movie returned with boxOffice and rating"
+        else
+            log_error "Single movie response is incorrect: $response"
+        fi
+    else
+        log_error "Failed to get single movie"
+        return
+    fi
+    
+    etag=$(response_header "ETag")
+    last_modified=$(response_header "Last-Modified")
+    if [[ -n "$etag" && -n "$last_modified" ]]; then
+        log_success "ETag and Last-Modified headers present"
+    else
+        log_error "Expected ETag and Last-Modified headers, got '$etag' and '$last_modified'"
+        return
+    fi
+    
+    log_info "Testing If-None-Match (expecting 304)..."
+    if make_raw_request "GET" "/movies/$single_title" 304 -H "If-None-Match: $etag" >/dev/null; then
+        log_success "Correctly returned 304 for matching If-None-Match"
+    else
+        log_error "Should return 304 for matching If-None-Match"
+    fi
+    
+    log_info "Testing If-Modified-Since (expecting 304)..."
+    if make_raw_request "GET" "/movies/$single_title" 304 -H "If-Modified-Since: $last_modified" >/dev/null; then
+        log_success "Correctly returned 304 for If-Modified-Since"
+    else
+        log_error "Should return 304 for If-Modified-Since"
+    fi
+    
+    log_info "Testing that a new rating changes the ETag..."
+    make_request "POST" "/movies/$single_title/ratings" "-H 'X-Rater-Id: e2e-$RUN_ID'" '{"rating": 4.0}' 201 >/dev/null
+    if make_raw_request "GET" "/movies/$single_title" 200 -H "If-None-Match: $etag" >/dev/null; then
+        log_success "ETag changed after rating"
+    else
+        log_error "ETag should change when the rating aggregate changes"
+    fi
+    
+    log_info "Getting non-existent movie (expecting 404)..."
+    if make_request "GET" "/movies/NonExistentMovie $RUN_ID" "" "" 404 >/dev/null; then
+        log_success "Correctly returned 404 for non-existent movie"
+    else
+        log_error "Should return 404 for non-existent movie"
+    fi
+}
+
+# Stage 8: Update and Delete
+stage8_update_delete() {
+    echo -e "\n${BLUE}=== STAGE 8: Update and Delete ===${NC}"
+    
+    update_title="E2E Update $RUN_ID"
+    other_title="E2E Other $RUN_ID"
+    log_info "Creating movies '$update_title' and '$other_title'..."
+    if ! create_movie "{\"title\":\"$update_title\",\"genre\":\"Drama\",\"releaseDate\":\"2020-02-02\",\"distributor\":\"E2E Studios\",\"budget\":1000,\"mpaRating\":\"PG\"}" >/dev/null ||
+        ! create_movie "{\"title\":\"$other_title\",\"genre\":\"Drama\",\"releaseDate\":\"2020-02-02\"}" >/dev/null; then
+        log_error "Failed to create movies for update tests"
+        return
+    fi
+    
+    make_raw_request "GET" "/movies/$update_title" 200 >/dev/null
+    etag=$(response_header "ETag")
+    
+    log_info "Patching '$update_title' with a matching If-Match..."
+    if response=$(make_raw_request "PATCH" "/movies/$update_title" 200 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: application/merge-patch+json" -H "If-Match: $etag" \
+        -d '{"budget": 2000, "mpaRating": null}'); then
+        if echo "$response" | jq -e '.budget == 2000 and .mpaRating == null and .distributor == "E2E Studios"' >/dev/null; then
+            log_success "Merge patch updated budget, cleared mpaRating and kept distributor"
+        else
+            log_error "Merge patch result is incorrect: $response"
+        fi
+    else
+        log_error "Failed to patch movie"
+    fi
+    new_etag=$(response_header "ETag")
+    
+    log_info "Patching with a stale If-Match (expecting 412)..."
+    if make_raw_request "PATCH" "/movies/$update_title" 412 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: application/merge-patch+json" -H "If-Match: $etag" \
+        -d '{"budget": 3000}' >/dev/null; then
+        log_success "Correctly returned 412 for stale If-Match"
+    else
+        log_error "Should return 412 for stale If-Match"
+    fi
+    
+    log_info "Patching with an unsupported media type (expecting 415)..."
+    if make_raw_request "PATCH" "/movies/$update_title" 415 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: text/plain" -d 'budget=3000' >/dev/null; then
+        log_success "Correctly returned 415 for unsupported patch media type"
+    else
+        log_error "Should return 415 for unsupported patch media type"
+    fi
+    
+    log_info "Replacing '$update_title' with PUT..."
+    update_title="E2E Updated $RUN_ID"
+    if response=$(make_raw_request "PUT" "/movies/E2E Update $RUN_ID" 200 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: application/json" -H "If-Match: $new_etag" \
+        -d "{\"title\":\"$update_title\",\"genre\":\"Comedy\",\"releaseDate\":\"2021-03-03\"}"); then
+        if echo "$response" | jq -e --arg t "$update_title" '.title == $t and .genre == "Comedy" and .distributor == null and .budget == null' >/dev/null; then
+            log_success "PUT replaced the movie and cleared omitted fields"
+        else
+            log_error "PUT result is incorrect: $response"
+        fi
+    else
+        log_error "Failed to replace movie"
+    fi
+    
+    log_info "Renaming onto an existing title (expecting 409)..."
+    if make_raw_request "PUT" "/movies/$update_title" 409 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: application/json" \
+        -d "{\"title\":\"$other_title\",\"genre\":\"Comedy\",\"releaseDate\":\"2021-03-03\"}" >/dev/null; then
+        log_success "Correctly returned 409 for duplicate title"
+    else
+        log_error "Should return 409 for duplicate title"
+    fi
+    
+    log_info "Replacing without Bearer token (expecting 401)..."
+    if make_raw_request "PUT" "/movies/$update_title" 401 -H "Content-Type: application/json" \
+        -d "{\"title\":\"$update_title\",\"genre\":\"Comedy\",\"releaseDate\":\"2021-03-03\"}" >/dev/null; then
+        log_success "Correctly returned 401 for missing Bearer token"
+    else
+        log_error "Should return 401 for missing Bearer token"
+    fi
+    
+    log_info "Deleting '$update_title'..."
+    if make_raw_request "DELETE" "/movies/$update_title" 204 -H "Authorization: Bearer $AUTH_TOKEN" >/dev/null; then
+        log_success "Movie deleted"
+        if make_request "GET" "/movies/$update_title" "" "" 404 >/dev/null; then
+            log_success "Deleted movie returns 404"
+        else
+            log_error "Deleted movie should return 404"
+        fi
+    else
+        log_error "Failed to delete movie"
+    fi
+    
+    if make_raw_request "DELETE" "/movies/$update_title" 404 -H "Authorization: Bearer $AUTH_TOKEN" >/dev/null; then
+        log_success "Correctly returned 404 for deleting a missing movie"
+    else
+        log_error "Should return 404 for deleting a missing movie"
+    fi
+}
+
+# Stage 9: Addressing Movies by ID
+stage9_address_by_id() {
+    echo -e "\n${BLUE}=== STAGE 9: Addressing Movies by ID ===${NC}"
+    
+    if [[ -z "$single_id" || "$single_id" == "null" ]]; then
+        log_error "No movie ID from stage 7, skipping ID addressing tests"
+        return
+    fi
+    
+    log_info "Getting movie by ID $single_id..."
+    if response=$(make_raw_request "GET" "/movies/$single_id" 200); then
+        if echo "$response" | jq -e --arg id "$single_id" --arg t "$single_title" '.id == $id and .title == $t' >/dev/null; then
+            log_success "Movie resolved by ID"
+        else
+            log_error "Movie by ID response is incorrect: $response"
+        fi
+        if [[ -n "$(response_header "Link")" ]]; then
+            log_success "Canonical Link header present"
+        else
+            log_error "Expected a canonical Link header"
+        fi
+    else
+        log_error "Failed to get movie by ID"
+    fi
+    
+    log_info "Getting rating aggregate by ID..."
+    if response=$(make_request "GET" "/movies/$single_id/rating" "" "" 200); then
+        if echo "$response" | jq -e 'has("average") and has("count")' >/dev/null; then
+            log_success "Rating aggregate resolved by ID"
+        else
+            log_error "Rating aggregate by ID is incorrect: $response"
+        fi
+    else
+        log_error "Failed to get rating aggregate by ID"
+    fi
+}
+
+# Stage 10: Box Office Refresh
+stage10_boxoffice_refresh() {
+    echo -e "\n${BLUE}=== STAGE 10: Box Office Refresh ===${NC}"
+    
+    log_info "Refreshing box office for '$single_title'..."
+    if response=$(make_raw_request "POST" "/movies/$single_title/boxoffice:refresh" 200 -H "Authorization: Bearer $AUTH_TOKEN"); then
+        status=$(echo "$response" | jq -r '.status')
+        if [[ "$status" =~ ^(updated|unchanged|not_found|failed)$ ]]; then
+            log_success "Refresh reported status: $status"
+        else
+            log_error "Unexpected refresh result: $response"
+        fi
+    else
+        log_error "Failed to refresh box office"
+    fi
+    
+    log_info "Refreshing without Bearer token (expecting 401)..."
+    if make_raw_request "POST" "/movies/$single_title/boxoffice:refresh" 401 >/dev/null; then
+        log_success "Correctly returned 401 for missing Bearer token"
+    else
+        log_error "Should return 401 for missing Bearer token"
+    fi
+    
+    log_info "Refreshing a non-existent movie (expecting 404)..."
+    if make_raw_request "POST" "/movies/NonExistentMovie $RUN_ID/boxoffice:refresh" 404 -H "Authorization: Bearer $AUTH_TOKEN" >/dev/null; then
+        log_success "Correctly returned 404 for non-existent movie"
+    else
+        log_error "Should return 404 for non-existent movie"
+    fi
+    
+    log_info "Bulk refreshing movies matching 'q=E2E Single $RUN_ID'..."
+    if response=$(make_raw_request "POST" "/movies/boxoffice:refresh?q=E2E%20Single%20$RUN_ID&limit=5" 200 -H "Authorization: Bearer $AUTH_TOKEN"); then
+        if echo "$response" | jq -e '(.items | length) >= 1 and (.summary | type == "object")' >/dev/null; then
+            log_success "Bulk refresh returned per-movie results and a summary"
+        else
+            log_error "Bulk refresh response is incorrect: $response"
+        fi
+    else
+        log_error "Failed to bulk refresh box office"
+    fi
+
+}
+
+# Stage 11: Box Office History
+stage11_boxoffice_history() {
+    echo -e "\n${BLUE}=== STAGE 11: Box Office History ===${NC}"
+    
+    log_info "Getting box office history for '$single_title'..."
+    if response=$(make_request "GET" "/movies/$single_title/boxoffice/history?from=2000-01-01" "" "" 200); then
+        if echo "$response" | jq -e --arg t "$single_title" '.title == $t and (.points | type == "array")' >/dev/null; then
+            points=$(echo "$response" | jq -r '.points | length')
+            log_success "History returned $points points"
+        else
+            log_error "History response is incorrect: $response"
+        fi
+    else
+        log_error "Failed to get box office history"
+    fi
+    
+    log_info "Getting history with an invalid bound (expecting 400)..."
+    if make_request "GET" "/movies/$single_title/boxoffice/history?from=yesterday" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for invalid from"
+    else
+        log_error "Should return 400 for invalid from"
+    fi
+    
+    log_info "Getting history with an empty range (expecting 400)..."
+    if make_request "GET" "/movies/$single_title/boxoffice/history?from=2024-02-01&to=2024-01-01" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for from after to"
+    else
+        log_error "Should return 400 for from after to"
+    fi
+    
+    log_info "Getting history for a non-existent movie (expecting 404)..."
+    if make_request "GET" "/movies/NonExistentMovie $RUN_ID/boxoffice/history" "" "" 404 >/dev/null; then
+        log_success "Correctly returned 404 for non-existent movie"
+    else
+        log_error "Should return 404 for non-existent movie"
+    fi
+}
+
+# Stage 12: Exchange Rates and Currency Conversion
+stage12_exchange_rates() {
+    echo -e "\n${BLUE}=== STAGE 12: Exchange Rates and Currency Conversion ===${NC}"
+    
+    # XTS is the ISO 4217 code reserved for testing, so real rates are untouched
+    log_info "Storing an XTS exchange rate..."
+    if response=$(make_raw_request "PUT" "/exchange-rates/XTS/2000-01-01" 200 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: application/json" -d '{"rate": 2.5}'); then
+        if echo "$response" | jq -e '.currency == "XTS" and .date == "2000-01-01" and .rate == 2.5' >/dev/null; then
+            log_success "Exchange rate stored"
+        else
+            log_error "Exchange rate response is incorrect: $response"
+        fi
+    else
+        log_error "Failed to store exchange rate"
+    fi
+    
+    log_info "Listing XTS exchange rates..."
+    if response=$(make_raw_request "GET" "/exchange-rates?currency=XTS" 200 -H "Authorization: Bearer $AUTH_TOKEN"); then
+        if echo "$response" | jq -e '.base == "USD" and any(.items[]; .date == "2000-01-01")' >/dev/null; then
+            log_success "Stored rate is listed"
+        else
+            log_error "Exchange rate list is incorrect: $response"
+        fi
+    else
+        log_error "Failed to list exchange rates"
+    fi
+    
+    log_info "Listing exchange rates without Bearer token (expecting 401)..."
+    if make_raw_request "GET" "/exchange-rates" 401 >/dev/null; then
+        log_success "Correctly returned 401 for missing Bearer token"
+    else
+        log_error "Should return 401 for missing Bearer token"
+    fi
+    
+    log_info "Storing a rate for the base currency (expecting 400)..."
+    if make_raw_request "PUT" "/exchange-rates/USD/2000-01-01" 400 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: application/json" -d '{"rate": 1}' >/dev/null; then
+        log_success "Correctly returned 400 for the base currency"
+    else
+        log_error "Should return 400 for the base currency"
+    fi
+    
+    log_info "Listing movies in XTS..."
+    if response=$(make_request "GET" "/movies?currency=XTS&limit=5" "" "" 200); then
+        if echo "$response" | jq -e '[.items[].boxOffice | select(. != null and .currency == "USD")] | length == 0' >/dev/null; then
+            log_success "USD box office figures were converted"
+        else
+            log_error "USD box office figures were not converted: $response"
+        fi
+    else
+        log_error "Failed to list movies in XTS"
+    fi
+    
+    log_info "Listing movies in a currency without rates (expecting 400)..."
+    if make_request "GET" "/movies?currency=XXX" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for unsupported currency"
+    else
+        log_error "Should return 400 for unsupported currency"
+    fi
+    
+    log_info "Deleting the XTS exchange rate..."
+    if make_raw_request "DELETE" "/exchange-rates/XTS/2000-01-01" 204 -H "Authorization: Bearer $AUTH_TOKEN" >/dev/null; then
+        log_success "Exchange rate deleted"
+    else
+        log_error "Failed to delete exchange rate"
+    fi
+    
+    if make_raw_request "DELETE" "/exchange-rates/XTS/2000-01-01" 404 -H "Authorization: Bearer $AUTH_TOKEN" >/dev/null; then
+        log_success "Correctly returned 404 for deleting a missing rate"
+    else
+        log_error "Should return 404 for deleting a missing rate"
+    fi
+}
+
+# Stage 13: Title Suggestions
+stage13_suggest() {
+    echo -e "\n${BLUE}=== STAGE 13: Title Suggestions ===${NC}"
+    
+    log_info "Suggesting titles for 'single $RUN_ID'..."
+    if response=$(make_request "GET" "/movies/suggest?q=single%20$RUN_ID" "" "" 200); then
+        if echo "$response" | jq -e --arg t "$single_title" 'any(.items[]; .title == $t and .releaseYear == 2020)' >/dev/null; then
+            log_success "Suggestion found from a later word of the title"
+        else
+            log_error "Expected '$single_title' among suggestions: $response"
+        fi
+    else
+        log_error "Failed to get suggestions"
+    fi
+    
+    log_info "Suggesting with different case..."
+    if response=$(make_request "GET" "/movies/suggest?q=E2E%20SINGLE%20$RUN_ID&limit=1" "" "" 200); then
+        if echo "$response" | jq -e --arg t "$single_title" '(.items | length) == 1 and .items[0].title == $t' >/dev/null; then
+            log_success "Suggestions are case-insensitive and honour limit"
+        else
+            log_error "Unexpected suggestions: $response"
+        fi
+    else
+        log_error "Failed to get suggestions"
+    fi
+    
+    log_info "Suggesting titles of a deleted movie..."
+    if response=$(make_request "GET" "/movies/suggest?q=E2E%20Updated%20$RUN_ID" "" "" 200); then
+        if echo "$response" | jq -e '(.items | length) == 0' >/dev/null; then
+            log_success "Deleted movie is no longer suggested"
+        else
+            log_error "Deleted movie is still suggested: $response"
+        fi
+    else
+        log_error "Failed to get suggestions"
+    fi
+    
+    log_info "Suggesting without q (expecting 400)..."
+    if make_request "GET" "/movies/suggest" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for missing q"
+    else
+        log_error "Should return 400 for missing q"
+    fi
+    
+    log_info "Suggesting with an out-of-range limit (expecting 400)..."
+    if make_request "GET" "/movies/suggest?q=e2e&limit=51" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for out-of-range limit"
+    else
+        log_error "Should return 400 for out-of-range limit"
+    fi
+}
+
+# Stage 14: Facet Counts
+stage14_facets() {
+    echo -e "\n${BLUE}=== STAGE 14: Facet Counts ===${NC}"
+    
+    log_info "Listing movies with genre and decade facets..."
+    if response=$(make_request "GET" "/movies?genre=Drama&facets=genre,decade&limit=1" "" "" 200); then
+        if echo "$response" | jq -e '(.facets.genre | length) == 1 and .facets.genre[0].value == "Drama" and .facets.genre[0].count >= 1' >/dev/null &&
+            echo "$response" | jq -e 'any(.facets.decade[]; .value == "2020s" and .count >= 1)' >/dev/null; then
+            log_success "Facets counted under the genre filter"
+        else
+            log_error "Facet counts are incorrect: $response"
+        fi
+    else
+        log_error "Failed to list movies with facets"
+    fi
+    
+    log_info "Counting facets under the fuzzy search fallback..."
+    if response=$(make_request "GET" "/movies?q=Singel&genre=Drama&facets=genre" "" "" 200); then
+        if echo "$response" | jq -e --arg t "$single_title" 'any(.items[]; .title == $t)' >/dev/null &&
+            echo "$response" | jq -e '.facets.genre[0].value == "Drama" and .facets.genre[0].count == (.items | length)' >/dev/null; then
+            log_success "Facets count the fuzzy results"
+        else
+            log_error "Facets under fuzzy fallback are incorrect: $response"
+        fi
+    else
+        log_error "Failed to list fuzzy results with facets"
+    fi
+    
+    log_info "Requesting an unknown facet (expecting 400)..."
+    if make_request "GET" "/movies?facets=budget" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for unknown facet"
+    else
+        log_error "Should return 400 for unknown facet"
+    fi
+}
+
+# Stage 15: Totals and Page Navigation
+stage15_page_navigation() {
+    echo -e "\n${BLUE}=== STAGE 15: Totals and Page Navigation ===${NC}"
+    
+    list_query="/movies?genre=Drama&year=2020&sort=title&limit=1"
+    log_info "Listing the first page with includeTotal=true..."
+    if ! first=$(make_request "GET" "$list_query&includeTotal=true" "" "" 200); then
+        log_error "Failed to list movies with includeTotal"
+        return
+    fi
+    total=$(echo "$first" | jq -r '.total')
+    if [[ "$total" =~ ^[0-9]+$ && "$total" -ge 2 ]]; then
+        log_success "Total returned: $total"
+    else
+        log_error "Expected a total of at least 2, got $total"
+    fi
+    if echo "$first" | jq -e 'has("prevCursor") | not' >/dev/null; then
+        log_success "First page has no prevCursor"
+    else
+        log_error "First page should not have a prevCursor"
+    fi
+    
+    next_cursor=$(echo "$first" | jq -r '.nextCursor')
+    if [[ "$next_cursor" == "null" || -z "$next_cursor" ]]; then
+        log_error "Expected a nextCursor on the first page"
+        return
+    fi
+    
+    log_info "Following nextCursor..."
+    encoded_cursor=$(echo -n "$next_cursor" | jq -sRr @uri)
+    if ! second=$(make_request "GET" "$list_query&cursor=$encoded_cursor" "" "" 200); then
+        log_error "Failed to get the second page"
+        return
+    fi
+    prev_cursor=$(echo "$second" | jq -r '.prevCursor')
+    if [[ "$prev_cursor" == "null" || -z "$prev_cursor" ]]; then
+        log_error "Expected a prevCursor on the second page"
+        return
+    fi
+    log_success "Second page has a prevCursor"
+    
+    log_info "Following prevCursor back to the first page..."
+    encoded_cursor=$(echo -n "$prev_cursor" | jq -sRr @uri)
+    if back=$(make_request "GET" "$list_query&cursor=$encoded_cursor" "" "" 200); then
+        if [[ "$(echo "$back" | jq -r '.items[0].id')" == "$(echo "$first" | jq -r '.items[0].id')" ]]; then
+            log_success "prevCursor returns to the first page"
+        else
+            log_error "prevCursor led to a different page: $back"
+        fi
+        if echo "$back" | jq -e 'has("prevCursor") | not' >/dev/null; then
+            log_success "Page reached backward at the start has no prevCursor"
+        else
+            log_error "Page reached backward at the start should not have a prevCursor"
+        fi
+    else
+        log_error "Failed to follow prevCursor"
+    fi
+    
+    log_info "Sending a tampered cursor (expecting 400)..."
+    tampered=$(echo -n "${next_cursor%.*}.AAAAAAAAAAAAAAAAAAAAAA" | jq -sRr @uri)
+    if make_request "GET" "$list_query&cursor=$tampered" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for tampered cursor"
+    else
+        log_error "Should return 400 for tampered cursor"
+    fi
+    
+    log_info "Reusing a cursor with another sort (expecting 400)..."
+    encoded_cursor=$(echo -n "$next_cursor" | jq -sRr @uri)
+    if make_request "GET" "/movies?genre=Drama&year=2020&sort=releaseDate&limit=1&cursor=$encoded_cursor" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for cursor from another sort"
+    else
+        log_error "Should return 400 for cursor from another sort"
+    fi
+}
+
+# Stage 16: Field Projection and Expansion
+stage16_projection() {
+    echo -e "\n${BLUE}=== STAGE 16: Field Projection and Expansion ===${NC}"
+    
+    log_info "Getting '$single_title' with fields=title..."
+    if response=$(make_request "GET" "/movies/$single_title?fields=title" "" "" 200); then
+        if echo "$response" | jq -e 'keys == ["id", "title"]' >/dev/null; then
+            log_success "Only id and title returned"
+        else
+            log_error "Projected movie is incorrect: $response"
+        fi
+    else
+        log_error "Failed to get projected movie"
+    fi
+    
+    log_info "Listing with fields=title,releaseDate and expand=rating..."
+    if response=$(make_request "GET" "/movies?q=E2E%20Single%20$RUN_ID&fields=title,releaseDate&expand=rating" "" "" 200); then
+        if echo "$response" | jq -e --arg t "$single_title" 'any(.items[]; .title == $t and (keys - ["id", "title", "releaseDate", "rating"] | length) == 0 and .rating.count >= 1)' >/dev/null; then
+            log_success "List items projected with the rating expanded"
+        else
+            log_error "Projected list is incorrect: $response"
+        fi
+    else
+        log_error "Failed to list projected movies"
+    fi
+    
+    log_info "Getting '$single_title' with expand=boxOfficeHistory..."
+    if make_request "GET" "/movies/$single_title?expand=boxOfficeHistory" "" "" 200 >/dev/null; then
+        log_success "Box office history expansion accepted"
+    else
+        log_error "Failed to expand box office history"
+    fi
+    
+    log_info "Requesting an unknown field (expecting 400)..."
+    if make_request "GET" "/movies?fields=popularity" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for unknown field"
+    else
+        log_error "Should return 400 for unknown field"
+    fi
+    
+    log_info "Requesting an unknown expansion (expecting 400)..."
+    if make_request "GET" "/movies/$single_title?expand=cast" "" "" 400 >/dev/null; then
+        log_success "Correctly returned 400 for unknown expansion"
+    else
+        log_error "Should return 400 for unknown expansion"
+    fi
+    
+    log_info "Bulk refreshing with fields (expecting 400)..."
+    if make_raw_request "POST" "/movies/boxoffice:refresh?fields=title" 400 -H "Authorization: Bearer $AUTH_TOKEN" >/dev/null; then
+        log_success "Correctly returned 400 for fields on bulk refresh"
+    else
+        log_error "Should return 400 for fields on bulk refresh"
+    fi
+    
+    log_info "Exporting with expand (expecting 400)..."
+    if make_raw_request "GET" "/movies:export?expand=rating" 400 -H "Authorization: Bearer $AUTH_TOKEN" >/dev/null; then
+        log_success "Correctly returned 400 for expand on export"
+    else
+        log_error "Should return 400 for expand on export"
+    fi
+}
+
+# Stage 17: Bulk Import
+stage17_import() {
+    echo -e "\n${BLUE}=== STAGE 17: Bulk Import ===${NC}"
+    
+    ndjson_body=$(printf '%s\n' \
+        "{\"title\":\"E2E Import A $RUN_ID\",\"genre\":\"Comedy\",\"releaseDate\":\"2019-05-05\"}" \
+        "{\"title\":\"E2E Import B $RUN_ID\",\"genre\":\"Comedy\",\"releaseDate\":\"2019-06-06\",\"budget\":5000}" \
+        "{\"title\":\"E2E Import C $RUN_ID\",\"genre\":\"Comedy\",\"releaseDate\":\"06/07/2019\"}" \
+        "{\"title\":\"$single_title\",\"genre\":\"Drama\",\"releaseDate\":\"2020-01-01\"}")
+    
+    log_info "Importing NDJSON..."
+    if response=$(make_raw_request "POST" "/movies:import" 200 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: application/x-ndjson" --data-binary "$ndjson_body"); then
+        if echo "$response" | jq -e '.summary == {"created": 2, "duplicate": 1, "invalid": 1}' >/dev/null; then
+            log_success "Import summary is correct"
+        else
+            log_error "Import summary is incorrect: $response"
+        fi
+        if echo "$response" | jq -e '[.results[] | [.line, .status]] == [[1, "created"], [2, "created"], [3, "invalid"], [4, "duplicate"]]' >/dev/null &&
+            echo "$response" | jq -e '.results[0].id != null and .results[2].reason != null' >/dev/null; then
+            log_success "Per-row results are reported in input order"
+        else
+            log_error "Per-row results are incorrect: $response"
+        fi
+    else
+        log_error "Failed to import NDJSON"
+    fi
+    
+    if make_request "GET" "/movies/E2E Import B $RUN_ID" "" "" 200 >/dev/null; then
+        log_success "Imported movie is readable"
+    else
+        log_error "Imported movie should be readable"
+    fi
+    
+    csv_body=$(printf '%s\n' "title,genre,releaseDate,budget" "\"E2E Import, CSV $RUN_ID\",Comedy,2019-07-07," "E2E Import D $RUN_ID,Comedy,2019-08-08,lots")
+    
+    log_info "Importing CSV with enrich=none..."
+    if response=$(make_raw_request "POST" "/movies:import?enrich=none" 200 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: text/csv" --data-binary "$csv_body"); then
+        if echo "$response" | jq -e '.summary.created == 1 and .summary.invalid == 1 and .results[0].line == 2' >/dev/null; then
+            log_success "CSV import reported per row"
+        else
+            log_error "CSV import report is incorrect: $response"
+        fi
+    else
+        log_error "Failed to import CSV"
+    fi
+    
+    log_info "Importing CSV with an unknown column (expecting 400)..."
+    if make_raw_request "POST" "/movies:import" 400 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: text/csv" --data-binary $'title,genre,releaseDate,stars\n' >/dev/null; then
+        log_success "Correctly returned 400 for unknown CSV column"
+    else
+        log_error "Should return 400 for unknown CSV column"
+    fi
+    
+    log_info "Importing with an invalid enrich mode (expecting 400)..."
+    if make_raw_request "POST" "/movies:import?enrich=inline" 400 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: application/x-ndjson" --data-binary "$ndjson_body" >/dev/null; then
+        log_success "Correctly returned 400 for invalid enrich mode"
+    else
+        log_error "Should return 400 for invalid enrich mode"
+    fi
+    
+    log_info "Importing JSON (expecting 415)..."
+    if make_raw_request "POST" "/movies:import" 415 \
+        -H "Authorization: Bearer $AUTH_TOKEN" -H "Content-Type: application/json" --data-binary '[]' >/dev/null; then
+        log_success "Correctly returned 415 for unsupported import media type"
+    else
+        log_error "Should return 415 for unsupported import media type"
+    fi
+    
+    log_info "Importing without Bearer token (expecting 401)..."
+    if make_raw_request "POST" "/movies:import" 401 -H "Content-Type: application/x-ndjson" --data-binary "$ndjson_body" >/dev/null; then
+        log_success "Correctly returned 401 for missing Bearer token"
+    else
+        log_error "Should return 401 for missing Bearer token"
+    fi
+}
+
+# Stage 18: Catalogue Export
+stage18_export() {
+    echo -e "\n${BLUE}=== STAGE 18: Catalogue Export ===${NC}"
+    
+    export_query="/movies:export?genre=Comedy&year=2019&sort=title"
+    import_title="E2E Import A $RUN_ID"
+    
+    log_info "Exporting NDJSON..."
+    if response=$(make_raw_request "GET" "$export_query&include=fieldSources" 200 -H "Authorization: Bearer $AUTH_TOKEN"); then
+        if echo "$response" | jq -e --arg t "$import_title" 'select(.title == $t) | has("rating") and has("fieldSources")' 2>/dev/null | grep -q true; then
+            log_success "NDJSON export includes the imported movie with rating and fieldSources"
+        else
+            log_error "NDJSON export is incorrect: $response"
+        fi
+        disposition=$(response_header "Content-Disposition")
+        if [[ "$disposition" == attachment*".ndjson\"" && "$(response_header "Content-Type")" == "application/x-ndjson" ]]; then
+            log_success "NDJSON export headers are correct"
+        else
+            log_error "Unexpected export headers: $disposition, $(response_header "Content-Type")"
+        fi
+    else
+        log_error "Failed to export NDJSON"
+    fi
+    
+    log_info "Exporting a JSON array..."
+    if response=$(make_raw_request "GET" "$export_query&format=json" 200 -H "Authorization: Bearer $AUTH_TOKEN"); then
+        if echo "$response" | jq -e --arg t "$import_title" 'type == "array" and any(.[]; .title == $t)' >/dev/null; then
+            log_success "JSON export is an array including the imported movie"
+        else
+            log_error "JSON export is incorrect: $response"
+        fi
+    else
+        log_error "Failed to export JSON"
+    fi
+    
+    log_info "Exporting CSV..."
+    if response=$(make_raw_request "GET" "$export_query&format=csv" 200 -H "Authorization: Bearer $AUTH_TOKEN"); then
+        header=$(echo "$response" | head -n 1 | tr -d '\r')
+        if [[ "$header" == title,genre,releaseDate,distributor,budget,mpaRating,id,* ]] && echo "$response" | grep -q "^$import_title,Comedy,2019-05-05,"; then
+            log_success "CSV export has the header and the imported movie"
+        else
+            log_error "CSV export is incorrect: $response"
+        fi
+    else
+        log_error "Failed to export CSV"
+    fi
+    
+    log_info "Exporting an unknown format (expecting 400)..."
+    if make_raw_request "GET" "/movies:export?format=xml" 400 -H "Authorization: Bearer $AUTH_TOKEN" >/dev/null; then
+        log_success "Correctly returned 400 for unknown export format"
+    else
+        log_error "Should return 400 for unknown export format"
+    fi
+    
+    log_info "Exporting without Bearer token (expecting 401)..."
+    if make_raw_request "GET" "/movies:export" 401 >/dev/null; then
+        log_success "Correctly returned 401 for missing Bearer token"
+    else
+        log_error "Should return 401 for missing Bearer token"
+    fi
+}
+
 # Main execution
 main() {
     echo -e "${GREEN}Starting E2E Tests for Movies API${NC}"
@@ -498,6 +1273,18 @@ main() {
     stage4_search_pagination
     stage5_auth_permissions
     stage6_error_handling
+    stage7_single_movie
+    stage8_update_delete
+    stage9_address_by_id
+    stage10_boxoffice_refresh
+    stage11_boxoffice_history
+    stage12_exchange_rates
+    stage13_suggest
+    stage14_facets
+    stage15_page_navigation
+    stage16_projection
+    stage17_import
+    stage18_export
     
     # Print summary
     echo -e "\n${BLUE}=== TEST SUMMARY ===${NC}"
@@ -2,12 +2,15 @@ module github.com/robin-camp/movies
 
 go 1.23
 
+require (
+	github.com/go-chi/chi/v5 v5.2.3
+	github.com/go-sql-driver/mysql v1.9.3
+	github.com/hashicorp/go-retryablehttp v0.7.8
+	github.com/jmoiron/sqlx v1.4.0
+	github.com/oklog/ulid/v2 v2.1.1
+)
+
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/go-chi/chi/v5 v5.2.3 // indirect
-	github.com/go-sql-driver/mysql v1.9.3 // indirect
 	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
-	github.com/hashicorp/go-retryablehttp v0.7.8 // indirect
-	github.com/jmoiron/sqlx v1.4.0 // indirect
-	github.com/oklog/ulid/v2 v2.1.1 // indirect
 )
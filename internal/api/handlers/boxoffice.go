@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/robin-camp/movies/internal/enrichment"
+	"github.com/robin-camp/movies/internal/store"
+)
+
+// maxRefreshBatch caps how many movies one bulk refresh call re-fetches.
+const maxRefreshBatch = 100
+
+// BoxOfficeHandler handles box office history and administrative endpoints.
+type BoxOfficeHandler struct {
+	movieStore *store.MovieStore
+	rateStore  *store.ExchangeRateStore
+	enricher   *enrichment.Enricher
+	listOpts   ListOptions
+	logger     *slog.Logger
+}
+
+// NewBoxOfficeHandler creates a BoxOfficeHandler.
+func NewBoxOfficeHandler(ms *store.MovieStore, xs *store.ExchangeRateStore, enricher *enrichment.Enricher, opts ListOptions, logger *slog.Logger) *BoxOfficeHandler {
+	return &BoxOfficeHandler{movieStore: ms, rateStore: xs, enricher: enricher, listOpts: opts, logger: logger}
+}
+
+// Refresh handles POST /movies/{title}/boxoffice:refresh.
+func (h *BoxOfficeHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	key := movieKey(r)
+	if key == "" {
+		writeError(w, "BAD_REQUEST", "Missing title parameter", http.StatusBadRequest)
+		return
+	}
+
+	movie, err := resolveMovie(r.Context(), h.movieStore, key)
+	if err != nil {
+		h.logger.Error("failed to get movie", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
+		return
+	}
+	if movie == nil {
+		writeError(w, "NOT_FOUND", "Movie not found", http.StatusNotFound)
+		return
+	}
+
+	result, err := h.enricher.Refresh(r.Context(), movie)
+	if err != nil {
+		h.logger.Error("failed to refresh box office", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to refresh box office", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Link", canonicalLink(r, movie))
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// RefreshMany handles POST /movies/boxoffice:refresh. It accepts the GET /movies
+// filters, except fields and expand, and refreshes one page of matches; follow
+// nextCursor for the rest.
+func (h *BoxOfficeHandler) RefreshMany(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFullFilters(r.URL.Query(), h.listOpts.CursorKey)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filters.Limit > maxRefreshBatch {
+		filters.Limit = maxRefreshBatch
+	}
+
+	// The lookups run one after another and a full batch takes longer than
+	// the server's default write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	page, err := h.movieStore.List(r.Context(), filters)
+	if err != nil {
+		h.logger.Error("failed to list movies", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to list movies", http.StatusInternalServerError)
+		return
+	}
+	movies := page.Movies
+
+	results := make([]*enrichment.RefreshResult, 0, len(movies))
+	summary := map[string]int{}
+	for i := range movies {
+		result, err := h.enricher.Refresh(r.Context(), &movies[i])
+		if err != nil {
+			h.logger.Error("failed to refresh box office", "title", movies[i].Title, "err", err)
+			result = &enrichment.RefreshResult{
+				MovieID: movies[i].ID,
+				Title:   movies[i].Title,
+				Status:  enrichment.RefreshFailed,
+				Error:   "internal error",
+			}
+		}
+		summary[result.Status]++
+		results = append(results, result)
+	}
+
+	resp := map[string]interface{}{"items": results, "summary": summary}
+	if page.Next != nil {
+		resp["nextCursor"] = h.listOpts.encodeCursor(page.Next)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// History handles GET /movies/{title}/boxoffice/history. The optional from and
+// to parameters take RFC 3339 timestamps or dates; a date in to includes that
+// whole day. With currency, each point is converted at the rate in effect when
+// it was reported.
+func (h *BoxOfficeHandler) History(w http.ResponseWriter, r *http.Request) {
+	key := movieKey(r)
+	if key == "" {
+		writeError(w, "BAD_REQUEST", "Missing title parameter", http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	from, err := parseTimeBound(q.Get("from"), false)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", "invalid from parameter", http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeBound(q.Get("to"), true)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", "invalid to parameter", http.StatusBadRequest)
+		return
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		writeError(w, "BAD_REQUEST", "from must be before to", http.StatusBadRequest)
+		return
+	}
+	currency, err := parseCurrency(q)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movie, err := resolveMovie(r.Context(), h.movieStore, key)
+	if err != nil {
+		h.logger.Error("failed to get movie", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
+		return
+	}
+	if movie == nil {
+		writeError(w, "NOT_FOUND", "Movie not found", http.StatusNotFound)
+		return
+	}
+
+	points, err := h.movieStore.ListBoxOfficeHistory(r.Context(), movie.ID, from, to)
+	if err != nil {
+		h.logger.Error("failed to list box office history", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to get box office history", http.StatusInternalServerError)
+		return
+	}
+	if currency != "" {
+		from := make([]string, 0, len(points))
+		for _, p := range points {
+			from = append(from, p.Currency)
+		}
+		table, err := rateTable(r.Context(), h.rateStore, currency, from)
+		if err != nil {
+			writeConversionError(w, h.logger, err)
+			return
+		}
+		for i := range points {
+			table.ConvertSnapshot(&points[i], currency)
+		}
+	}
+
+	w.Header().Set("Link", canonicalLink(r, movie))
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"movieId": movie.ID,
+		"title":   movie.Title,
+		"points":  points,
+	})
+}
+
+// parseTimeBound parses an RFC 3339 timestamp or a YYYY-MM-DD date. With
+// endOfDay set, a bare date is moved to the start of the following day so it
+// works as an exclusive upper bound covering the whole day.
+func parseTimeBound(s string, endOfDay bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q", s)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/robin-camp/movies/internal/store"
+)
+
+// Export formats.
+const (
+	exportNDJSON = "ndjson"
+	exportJSON   = "json"
+	exportCSV    = "csv"
+)
+
+// exportColumns are the CSV export columns. The first six are importColumns;
+// keeping only those gives a file POST /movies:import accepts.
+var exportColumns = []string{
+	"title", "genre", "releaseDate", "distributor", "budget", "mpaRating",
+	"id", "worldwideGross", "domesticGross", "internationalGross", "openingWeekendUSA",
+	"theaterCount", "weeksInRelease", "boxOfficeCurrency", "boxOfficeSource", "boxOfficeLastUpdated",
+	"averageRating", "ratingCount",
+}
+
+// Export handles GET /movies:export. It streams every movie matching the
+// GET /movies filters, in the requested sort order, as NDJSON (one Movie per
+// line), a JSON array of Movies or CSV, with box office and rating aggregates.
+// include=fieldSources adds provenance as on other reads. limit and cursor do
+// not apply, and fields and expand are rejected. A failure after the first
+// batch has been sent aborts the connection, so clients see a truncated
+// transfer rather than a short export.
+func (h *MovieHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = exportNDJSON
+	case exportNDJSON, exportJSON, exportCSV:
+	default:
+		writeError(w, "BAD_REQUEST", "format must be ndjson, json or csv", http.StatusBadRequest)
+		return
+	}
+	filters, err := parseFullFilters(r.URL.Query(), h.listOpts.CursorKey)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Large catalogues take longer than the server's default write timeout.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	var write func(m *store.Movie) error
+	var csvWriter *csv.Writer
+	switch format {
+	case exportNDJSON:
+		enc := json.NewEncoder(w)
+		write = func(m *store.Movie) error { return enc.Encode(m) }
+	case exportJSON:
+		// Elements are written one at a time, separated by commas, so the
+		// array is never held in memory.
+		enc, first := json.NewEncoder(w), true
+		write = func(m *store.Movie) error {
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			return enc.Encode(m)
+		}
+	case exportCSV:
+		csvWriter = csv.NewWriter(w)
+		write = func(m *store.Movie) error { return csvWriter.Write(exportRecord(m)) }
+	}
+
+	started := false
+	start := func() error {
+		started = true
+		filename := "movies-" + time.Now().UTC().Format("20060102T150405Z") + "." + format
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		switch format {
+		case exportJSON:
+			w.Header().Set("Content-Type", "application/json")
+			_, err := io.WriteString(w, "[")
+			return err
+		case exportCSV:
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			return csvWriter.Write(exportColumns)
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		return nil
+	}
+
+	err = h.movieStore.Export(r.Context(), filters, func(movies []store.Movie) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for i := range movies {
+			movies[i].CanonicalURL = buildAbsoluteURL(r, canonicalPath(&movies[i]))
+			applyIncludes(r, &movies[i])
+			if err := write(&movies[i]); err != nil {
+				return err
+			}
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil && format == exportJSON {
+		_, err = io.WriteString(w, "]\n")
+	}
+	if err == nil && csvWriter != nil {
+		csvWriter.Flush()
+		err = csvWriter.Error()
+	}
+	if err == nil {
+		return
+	}
+
+	if r.Context().Err() != nil {
+		return // the client went away
+	}
+	h.logger.Error("movie export failed", "err", err, "started", started)
+	if !started {
+		writeError(w, "INTERNAL_ERROR", "Failed to export movies", http.StatusInternalServerError)
+		return
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// exportRecord renders m as a CSV row in exportColumns order. Missing values
+// are empty cells.
+func exportRecord(m *store.Movie) []string {
+	record := []string{
+		m.Title, m.Genre, m.ReleaseDate.Format("2006-01-02"),
+		optString(m.Distributor), optInt64(m.Budget), optString(m.MPARating),
+		m.ID,
+	}
+	if bo := m.BoxOffice; bo != nil {
+		record = append(record,
+			strconv.FormatInt(bo.Revenue.Worldwide, 10), optInt64(bo.Revenue.Domestic),
+			optInt64(bo.Revenue.International), optInt64(bo.Revenue.OpeningWeekendUSA),
+			optInt(bo.TheaterCount), optInt(bo.WeeksInRelease),
+			bo.Currency, bo.Source, bo.LastUpdated.UTC().Format(time.RFC3339))
+	} else {
+		record = append(record, make([]string, 9)...)
+	}
+	if rt := m.Rating; rt != nil {
+		record = append(record, strconv.FormatFloat(rt.Average, 'f', 1, 64), strconv.Itoa(rt.Count))
+	} else {
+		record = append(record, "", "")
+	}
+	return record
+}
+
+func optString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optInt64(n *int64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatInt(*n, 10)
+}
+
+func optInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -14,19 +20,43 @@ import (
 
 	"github.com/robin-camp/movies/internal/api/middleware"
 	"github.com/robin-camp/movies/internal/clients/boxoffice"
+	"github.com/robin-camp/movies/internal/enrichment"
+	"github.com/robin-camp/movies/internal/search"
 	"github.com/robin-camp/movies/internal/store"
 )
 
 // MovieHandler handles movie-related endpoints.
 type MovieHandler struct {
-	movieStore *store.MovieStore
-	boClient   *boxoffice.Client
-	logger     *slog.Logger
+	movieStore  *store.MovieStore
+	ratingStore *store.RatingStore
+	jobStore    *store.JobStore
+	rateStore   *store.ExchangeRateStore
+	facets      *store.FacetCache
+	enricher    *enrichment.Enricher
+	titles      *search.TitleIndex
+	listOpts    ListOptions
+	logger      *slog.Logger
 }
 
-// NewMovieHandler creates a MovieHandler.
-func NewMovieHandler(ms *store.MovieStore, bo *boxoffice.Client, logger *slog.Logger) *MovieHandler {
-	return &MovieHandler{movieStore: ms, boClient: bo, logger: logger}
+// ListOptions configures the paginated list endpoints.
+type ListOptions struct {
+	CursorKey    []byte        // HMAC key signing pagination cursors
+	CountTimeout time.Duration // how long an exact includeTotal count may run; zero is unbounded
+}
+
+// encodeCursor signs c for a response; a nil cursor encodes as "".
+func (o ListOptions) encodeCursor(c *store.Cursor) string {
+	if c == nil {
+		return ""
+	}
+	encoded, _ := store.EncodeCursor(*c, o.CursorKey)
+	return encoded
+}
+
+// NewMovieHandler creates a MovieHandler. titles is kept current with the
+// movies the handler creates, updates and deletes.
+func NewMovieHandler(ms *store.MovieStore, rs *store.RatingStore, js *store.JobStore, xs *store.ExchangeRateStore, fc *store.FacetCache, enricher *enrichment.Enricher, titles *search.TitleIndex, opts ListOptions, logger *slog.Logger) *MovieHandler {
+	return &MovieHandler{movieStore: ms, ratingStore: rs, jobStore: js, rateStore: xs, facets: fc, enricher: enricher, titles: titles, listOpts: opts, logger: logger}
 }
 
 // CreateRequest represents POST /movies body.
@@ -39,6 +69,19 @@ type CreateRequest struct {
 	MPARating   *string `json:"mpaRating,omitempty"`
 }
 
+// validate checks the required fields and parses the release date.
+func (req *CreateRequest) validate() (time.Time, error) {
+	if req.Title == "" || req.Genre == "" || req.ReleaseDate == "" {
+		return time.Time{}, errors.New("title, genre, and releaseDate are required")
+	}
+
+	releaseDate, err := time.Parse("2006-01-02", req.ReleaseDate)
+	if err != nil {
+		return time.Time{}, errors.New("releaseDate must be in YYYY-MM-DD format")
+	}
+	return releaseDate, nil
+}
+
 // Create handles POST /movies.
 func (h *MovieHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req CreateRequest
@@ -47,14 +90,9 @@ func (h *MovieHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Title == "" || req.Genre == "" || req.ReleaseDate == "" {
-		writeError(w, "BAD_REQUEST", "title, genre, and releaseDate are required", http.StatusBadRequest)
-		return
-	}
-
-	releaseDate, err := time.Parse("2006-01-02", req.ReleaseDate)
+	releaseDate, err := req.validate()
 	if err != nil {
-		writeError(w, "BAD_REQUEST", "releaseDate must be in YYYY-MM-DD format", http.StatusBadRequest)
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -68,157 +106,615 @@ func (h *MovieHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Budget:      req.Budget,
 		MPARating:   req.MPARating,
 	}
+	movie.FieldSources = userSources(nil, &req, allFields)
+
+	if preferAsync(r) {
+		h.createAsync(w, r, movie)
+		return
+	}
+
+	// Enrich with box office data, then store the movie and its box office row together
+	bo, err := h.enricher.Prepare(r.Context(), movie)
+	if err != nil {
+		h.logger.Warn("box office enrichment skipped", "title", movie.Title, "err", err)
+	}
 
-	if err := h.movieStore.Create(r.Context(), movie); err != nil {
+	if err := h.movieStore.CreateEnriched(r.Context(), movie, bo); err != nil {
 		h.logger.Error("failed to create movie", "err", err)
 		writeError(w, "INTERNAL_ERROR", "Failed to create movie", http.StatusInternalServerError)
 		return
 	}
+	h.titles.Put(movie.Suggestion())
 
-	// Enrich with box office data
-	h.enrichBoxOffice(r.Context(), movie)
-
-	// Reload box office data if present
-	bo, _ := h.movieStore.GetBoxOffice(r.Context(), movieID)
-	if bo != nil {
-		movie.BoxOffice = &store.BoxOffice{
-			Currency:    bo.Currency,
-			Source:      bo.Source,
-			LastUpdated: bo.LastReported,
-		}
-		movie.BoxOffice.Revenue.Worldwide = bo.GrossUSD
-		if bo.OpeningWeekendUSA != nil {
-			movie.BoxOffice.Revenue.OpeningWeekendUSA = bo.OpeningWeekendUSA
-		}
+	// Reload box office data if present, so the response matches later reads
+	if stored, _ := h.movieStore.GetBoxOffice(r.Context(), movieID); stored != nil {
+		movie.BoxOffice = stored.ToBoxOffice()
 	}
 
+	applyIncludes(r, movie)
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Location", buildAbsoluteURL(r, "/movies/"+movie.Title))
+	setCanonical(w, r, movie)
+	w.Header().Set("Location", movie.CanonicalURL)
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(movie)
 }
 
-func (h *MovieHandler) enrichBoxOffice(ctx context.Context, movie *store.Movie) {
-	boResp, err := h.boClient.GetByTitle(ctx, movie.Title)
-	if err != nil || boResp == nil {
-		h.logger.Warn("box office enrichment skipped", "title", movie.Title, "err", err)
+// createAsync stores the movie, queues its enrichment and answers 202 without
+// waiting for the upstream; progress is reported under the movie's enrichment field.
+func (h *MovieHandler) createAsync(w http.ResponseWriter, r *http.Request, movie *store.Movie) {
+	if err := h.movieStore.CreateWithEnrichmentJob(r.Context(), movie); err != nil {
+		h.logger.Error("failed to create movie", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to create movie", http.StatusInternalServerError)
 		return
 	}
+	h.titles.Put(movie.Suggestion())
+	movie.Enrichment = &store.EnrichmentStatus{Status: store.JobPending}
+	applyIncludes(r, movie)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Preference-Applied", "respond-async")
+	setCanonical(w, r, movie)
+	w.Header().Set("Location", movie.CanonicalURL)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(movie)
+}
+
+// preferAsync reports whether the client sent Prefer: respond-async (RFC 7240).
+func preferAsync(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(pref), "=")
+			if strings.EqualFold(strings.TrimSpace(name), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	// User-provided values take precedence
-	if movie.Distributor == nil && boResp.Distributor != "" {
-		movie.Distributor = &boResp.Distributor
+// List handles GET /movies. Requested facet counts and the total are computed
+// alongside the page.
+func (h *MovieHandler) List(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseListFilters(r.URL.Query(), h.listOpts.CursorKey)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
 	}
-	if movie.Budget == nil && boResp.Budget > 0 {
-		movie.Budget = &boResp.Budget
+	facetNames, err := parseFacets(r.URL.Query())
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
 	}
-	if movie.MPARating == nil && boResp.MPARating != "" {
-		movie.MPARating = &boResp.MPARating
+	currency, err := parseCurrency(r.URL.Query())
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	includeTotal := false
+	if tStr := r.URL.Query().Get("includeTotal"); tStr != "" {
+		if includeTotal, err = strconv.ParseBool(tStr); err != nil {
+			writeError(w, "BAD_REQUEST", "invalid includeTotal parameter", http.StatusBadRequest)
+			return
+		}
 	}
 
-	// Store box office data
-	boRow := &store.BoxOfficeRow{
-		MovieID:      movie.ID,
-		GrossUSD:     boResp.Revenue.Worldwide,
-		Currency:     "USD",
-		Source:       "ExampleBoxOfficeAPI",
-		LastReported: time.Now().UTC(),
+	type facetResult struct {
+		counts map[string][]store.FacetCount
+		err    error
+	}
+	var facetCh chan facetResult
+	if len(facetNames) > 0 {
+		facetCh = make(chan facetResult, 1)
+		go func() {
+			counts, err := h.facets.Facets(r.Context(), filters, facetNames)
+			facetCh <- facetResult{counts, err}
+		}()
 	}
-	if boResp.Revenue.OpeningWeekendUSA > 0 {
-		boRow.OpeningWeekendUSA = &boResp.Revenue.OpeningWeekendUSA
+	type totalResult struct {
+		total store.Total
+		err   error
+	}
+	var totalCh chan totalResult
+	if includeTotal {
+		totalCh = make(chan totalResult, 1)
+		go func() {
+			total, err := h.movieStore.Count(r.Context(), filters, h.listOpts.CountTimeout)
+			totalCh <- totalResult{total, err}
+		}()
 	}
 
-	if err := h.movieStore.SetBoxOffice(ctx, movie.ID, boRow); err != nil {
-		h.logger.Warn("failed to store box office data", "err", err)
+	page, err := h.movieStore.List(r.Context(), filters)
+	if err != nil {
+		h.logger.Error("failed to list movies", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to list movies", http.StatusInternalServerError)
+		return
 	}
-}
+	movies := page.Movies
 
-// List handles GET /movies.
-func (h *MovieHandler) List(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query()
+	items := make([]*store.Movie, len(movies))
+	for i := range movies {
+		movies[i].CanonicalURL = buildAbsoluteURL(r, canonicalPath(&movies[i]))
+		applyIncludes(r, &movies[i])
+		items[i] = &movies[i]
+	}
+	if currency != "" {
+		if err := convertMovies(r.Context(), h.rateStore, currency, items...); err != nil {
+			writeConversionError(w, h.logger, err)
+			return
+		}
+	}
 
-	var year *int
-	if yStr := q.Get("year"); yStr != "" {
-		y, err := strconv.Atoi(yStr)
-		if err != nil {
-			writeError(w, "BAD_REQUEST", "Invalid year parameter", http.StatusBadRequest)
+	// Validated by parseListFilters already.
+	proj, _ := parseProjection(r.URL.Query())
+	rendered := make([]interface{}, len(items))
+	for i, m := range items {
+		if rendered[i], err = proj.render(m); err != nil {
+			h.logger.Error("failed to render movie", "err", err)
+			writeError(w, "INTERNAL_ERROR", "Failed to list movies", http.StatusInternalServerError)
 			return
 		}
-		year = &y
 	}
 
-	var budget *int64
-	if bStr := q.Get("budget"); bStr != "" {
-		b, err := strconv.ParseInt(bStr, 10, 64)
-		if err != nil {
-			writeError(w, "BAD_REQUEST", "Invalid budget parameter", http.StatusBadRequest)
+	resp := map[string]interface{}{"items": rendered}
+	if page.Next != nil {
+		resp["nextCursor"] = h.listOpts.encodeCursor(page.Next)
+	}
+	if page.Prev != nil {
+		resp["prevCursor"] = h.listOpts.encodeCursor(page.Prev)
+	}
+	if totalCh != nil {
+		res := <-totalCh
+		if res.err != nil {
+			h.logger.Error("failed to count movies", "err", res.err)
+			writeError(w, "INTERNAL_ERROR", "Failed to list movies", http.StatusInternalServerError)
 			return
 		}
-		budget = &b
+		// The fuzzy fallback is a single page holding every match it kept.
+		if page.Fuzzy {
+			res.total = store.Total{Count: int64(len(movies)), Exact: true}
+		}
+		resp["total"] = res.total.Count
+		if !res.total.Exact {
+			resp["totalEstimated"] = true
+		}
+	}
+	if facetCh != nil {
+		res := <-facetCh
+		// Facets were counted over the exact matches, of which there are none;
+		// count the fuzzy page instead, which is every match the fallback kept.
+		if res.err == nil && page.Fuzzy {
+			ids := make([]string, len(movies))
+			for i := range movies {
+				ids[i] = movies[i].ID
+			}
+			res.counts, res.err = h.movieStore.FacetsOf(r.Context(), ids, facetNames)
+		}
+		if res.err != nil {
+			h.logger.Error("failed to count facets", "err", res.err)
+			writeError(w, "INTERNAL_ERROR", "Failed to list movies", http.StatusInternalServerError)
+			return
+		}
+		resp["facets"] = res.counts
 	}
 
-	limit := 20
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// parseFacets reads the facets parameter as a list of distinct facet names.
+func parseFacets(q url.Values) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range splitList(q["facets"]) {
+		if !store.ValidFacet(name) {
+			return nil, fmt.Errorf("invalid facet %q", name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Suggestion limits for GET /movies/suggest.
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 50
+)
+
+// Suggest handles GET /movies/suggest, answering from the in-memory title index.
+func (h *MovieHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	prefix := strings.TrimSpace(q.Get("q"))
+	if prefix == "" {
+		writeError(w, "BAD_REQUEST", "q is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultSuggestLimit
 	if lStr := q.Get("limit"); lStr != "" {
 		l, err := strconv.Atoi(lStr)
-		if err != nil || l < 1 {
-			writeError(w, "BAD_REQUEST", "Invalid limit parameter", http.StatusBadRequest)
+		if err != nil || l < 1 || l > maxSuggestLimit {
+			writeError(w, "BAD_REQUEST", fmt.Sprintf("limit must be between 1 and %d", maxSuggestLimit), http.StatusBadRequest)
 			return
 		}
 		limit = l
 	}
 
-	var cursor *store.Cursor
-	if cStr := q.Get("cursor"); cStr != "" {
-		c, err := store.DecodeCursor(cStr)
-		if err != nil {
-			writeError(w, "BAD_REQUEST", "Invalid cursor parameter", http.StatusBadRequest)
+	items := h.titles.Suggest(prefix, limit)
+	if items == nil {
+		items = []search.Suggestion{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+}
+
+// Get handles GET /movies/{title}.
+func (h *MovieHandler) Get(w http.ResponseWriter, r *http.Request) {
+	key := movieKey(r)
+	if key == "" {
+		writeError(w, "BAD_REQUEST", "Missing title parameter", http.StatusBadRequest)
+		return
+	}
+
+	movie, err := resolveMovie(r.Context(), h.movieStore, key)
+	if err != nil {
+		h.logger.Error("failed to get movie", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
+		return
+	}
+	if movie == nil {
+		writeError(w, "NOT_FOUND", "Movie not found", http.StatusNotFound)
+		return
+	}
+
+	currency, err := parseCurrency(r.URL.Query())
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	proj, err := parseProjection(r.URL.Query())
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Converted figures also depend on the exchange rates, which the movie's
+	// validators do not cover, so conditional requests only apply unconverted.
+	modified, err := h.movieStore.LastModified(r.Context(), movie.ID)
+	if err != nil {
+		h.logger.Error("failed to get movie", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
+		return
+	}
+	setValidators(w, modified)
+	if currency == "" && notModified(r, movieETag(modified), modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if err := h.hydrate(r.Context(), movie); err != nil {
+		h.logger.Error("failed to load movie details", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
+		return
+	}
+	if proj.history {
+		if movie.BoxOfficeHistory, err = h.movieStore.ListBoxOfficeHistory(r.Context(), movie.ID, nil, nil); err != nil {
+			h.logger.Error("failed to load box office history", "err", err)
+			writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
 			return
 		}
-		cursor = c
 	}
-
-	filters := store.ListFilters{
-		Query:       q.Get("q"),
-		Year:        year,
-		Genre:       q.Get("genre"),
-		Distributor: q.Get("distributor"),
-		Budget:      budget,
-		MPARating:   q.Get("mpaRating"),
-		Limit:       limit,
-		Cursor:      cursor,
+	if currency != "" {
+		if err := convertMovies(r.Context(), h.rateStore, currency, movie); err != nil {
+			writeConversionError(w, h.logger, err)
+			return
+		}
 	}
 
-	movies, nextCursor, err := h.movieStore.List(r.Context(), filters)
+	applyIncludes(r, movie)
+	setCanonical(w, r, movie)
+	body, err := proj.render(movie)
 	if err != nil {
-		h.logger.Error("failed to list movies", "err", err)
-		writeError(w, "INTERNAL_ERROR", "Failed to list movies", http.StatusInternalServerError)
+		h.logger.Error("failed to render movie", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
 
-	// Enrich with box office data
-	for i := range movies {
-		bo, _ := h.movieStore.GetBoxOffice(r.Context(), movies[i].ID)
-		if bo != nil {
-			movies[i].BoxOffice = &store.BoxOffice{
-				Currency:    bo.Currency,
-				Source:      bo.Source,
-				LastUpdated: bo.LastReported,
-			}
-			movies[i].BoxOffice.Revenue.Worldwide = bo.GrossUSD
-			if bo.OpeningWeekendUSA != nil {
-				movies[i].BoxOffice.Revenue.OpeningWeekendUSA = bo.OpeningWeekendUSA
-			}
+// Replace handles PUT /movies/{title}.
+func (h *MovieHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.loadForWrite(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	h.update(w, r, current, req, allFields)
+}
+
+// Patch handles PATCH /movies/{title} using JSON Merge Patch (RFC 7396).
+func (h *MovieHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, _ := mime.ParseMediaType(ct)
+		if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+			writeError(w, "UNSUPPORTED_MEDIA_TYPE", "Content-Type must be application/merge-patch+json", http.StatusUnsupportedMediaType)
+			return
 		}
 	}
 
-	resp := map[string]interface{}{"items": movies}
-	if nextCursor != nil {
-		encoded, _ := store.EncodeCursor(*nextCursor)
-		resp["nextCursor"] = encoded
+	current, ok := h.loadForWrite(w, r)
+	if !ok {
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		writeError(w, "BAD_REQUEST", "Request body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := toDocument(requestFromMovie(current))
+	if err != nil {
+		h.logger.Error("failed to encode movie for patch", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to update movie", http.StatusInternalServerError)
+		return
 	}
 
+	patched, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		writeError(w, "BAD_REQUEST", "Invalid patch document", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateRequest
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "Invalid patch document", http.StatusBadRequest)
+		return
+	}
+
+	h.update(w, r, current, req, func(field string) bool {
+		_, ok := patch[field]
+		return ok
+	})
+}
+
+// Delete handles DELETE /movies/{title}.
+func (h *MovieHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.loadForWrite(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.movieStore.Delete(r.Context(), current.ID, expectedVersion(r, current)); err != nil {
+		h.writeMutationError(w, err, "Failed to delete movie")
+		return
+	}
+	h.titles.Remove(current.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadForWrite resolves the target movie and evaluates If-Match against it.
+func (h *MovieHandler) loadForWrite(w http.ResponseWriter, r *http.Request) (*store.Movie, bool) {
+	key := movieKey(r)
+	if key == "" {
+		writeError(w, "BAD_REQUEST", "Missing title parameter", http.StatusBadRequest)
+		return nil, false
+	}
+
+	movie, err := resolveMovie(r.Context(), h.movieStore, key)
+	if err != nil {
+		h.logger.Error("failed to get movie", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
+		return nil, false
+	}
+	if movie == nil {
+		writeError(w, "NOT_FOUND", "Movie not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	modified, err := h.movieStore.LastModified(r.Context(), movie.ID)
+	if err != nil {
+		h.logger.Error("failed to get movie", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !ifMatch(r, movieETag(modified)) {
+		writeError(w, "PRECONDITION_FAILED", "Movie has been modified", http.StatusPreconditionFailed)
+		return nil, false
+	}
+
+	return movie, true
+}
+
+// update validates req and writes it over current. written reports which
+// provenance-tracked fields the client actually sent.
+func (h *MovieHandler) update(w http.ResponseWriter, r *http.Request, current *store.Movie, req CreateRequest, written func(field string) bool) {
+	releaseDate, err := req.validate()
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movie := &store.Movie{
+		ID:           current.ID,
+		Title:        req.Title,
+		ReleaseDate:  releaseDate,
+		Genre:        req.Genre,
+		Distributor:  req.Distributor,
+		Budget:       req.Budget,
+		MPARating:    req.MPARating,
+		FieldSources: userSources(current.FieldSources, &req, written),
+	}
+
+	if err := h.movieStore.Update(r.Context(), movie, expectedVersion(r, current)); err != nil {
+		h.writeMutationError(w, err, "Failed to update movie")
+		return
+	}
+	h.titles.Put(movie.Suggestion())
+
+	if err := h.hydrate(r.Context(), movie); err != nil {
+		h.logger.Error("failed to load movie details", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to update movie", http.StatusInternalServerError)
+		return
+	}
+
+	modified, err := h.movieStore.LastModified(r.Context(), movie.ID)
+	if err != nil {
+		h.logger.Error("failed to load movie details", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to update movie", http.StatusInternalServerError)
+		return
+	}
+
+	applyIncludes(r, movie)
+	setValidators(w, modified)
+	setCanonical(w, r, movie)
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+	_ = json.NewEncoder(w).Encode(movie)
+}
+
+func (h *MovieHandler) writeMutationError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, "NOT_FOUND", "Movie not found", http.StatusNotFound)
+	case errors.Is(err, store.ErrPreconditionFailed):
+		writeError(w, "PRECONDITION_FAILED", "Movie has been modified", http.StatusPreconditionFailed)
+	case errors.Is(err, store.ErrDuplicateTitle):
+		writeError(w, "CONFLICT", "A movie with this title already exists", http.StatusConflict)
+	default:
+		h.logger.Error("movie mutation failed", "err", err)
+		writeError(w, "INTERNAL_ERROR", message, http.StatusInternalServerError)
+	}
+}
+
+// hydrate attaches box office data, the rating aggregate and the latest
+// enrichment job status to a movie.
+func (h *MovieHandler) hydrate(ctx context.Context, movie *store.Movie) error {
+	bo, err := h.movieStore.GetBoxOffice(ctx, movie.ID)
+	if err != nil {
+		return err
+	}
+	if bo != nil {
+		movie.BoxOffice = bo.ToBoxOffice()
+	}
+
+	agg, err := h.ratingStore.GetAggregate(ctx, movie.ID)
+	if err != nil {
+		return err
+	}
+	movie.Rating = agg
+
+	job, err := h.jobStore.LatestForMovie(ctx, movie.ID)
+	if err != nil {
+		return err
+	}
+	if job != nil {
+		movie.Enrichment = job.ToStatus()
+	}
+	return nil
+}
+
+// allFields marks every provenance-tracked field as written by the client.
+func allFields(string) bool { return true }
+
+// userSources derives provenance after a client write: fields the client set
+// become user-owned, fields it cleared lose their source, others keep base.
+func userSources(base store.FieldSources, req *CreateRequest, written func(field string) bool) store.FieldSources {
+	out := store.FieldSources{}
+	for field, source := range base {
+		out[field] = source
+	}
+
+	set := func(field string, present bool) {
+		if !written(field) {
+			return
+		}
+		if present {
+			out[field] = store.SourceUser
+		} else {
+			delete(out, field)
+		}
+	}
+	set(store.FieldDistributor, req.Distributor != nil)
+	set(store.FieldBudget, req.Budget != nil)
+	set(store.FieldMPARating, req.MPARating != nil)
+	return out
+}
+
+// applyIncludes strips optional response members the client did not ask for
+// through the include or fields query parameters.
+func applyIncludes(r *http.Request, m *store.Movie) {
+	if !includes(r, "fieldSources") && !slices.Contains(splitList(r.URL.Query()["fields"]), store.FieldFieldSources) {
+		m.FieldSources = nil
+	}
+}
+
+// includes reports whether the include query parameter lists name.
+func includes(r *http.Request, name string) bool {
+	for _, inc := range splitList(r.URL.Query()["include"]) {
+		if inc == name {
+			return true
+		}
+	}
+	return false
+}
+
+// requestFromMovie renders a stored movie in the writable request shape.
+func requestFromMovie(m *store.Movie) CreateRequest {
+	return CreateRequest{
+		Title:       m.Title,
+		Genre:       m.Genre,
+		ReleaseDate: m.ReleaseDate.Format("2006-01-02"),
+		Distributor: m.Distributor,
+		Budget:      m.Budget,
+		MPARating:   m.MPARating,
+	}
+}
+
+func toDocument(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// mergePatch applies an RFC 7396 merge patch to target: null removes a member,
+// objects merge recursively and every other value replaces the target member.
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if sub, ok := value.(map[string]interface{}); ok {
+			existing, _ := target[key].(map[string]interface{})
+			target[key] = mergePatch(existing, sub)
+			continue
+		}
+		target[key] = value
+	}
+	return target
 }
 
 // RatingHandler handles rating endpoints.
@@ -240,8 +736,8 @@ type SubmitRequest struct {
 
 // SubmitRating handles POST /movies/{title}/ratings.
 func (h *RatingHandler) SubmitRating(w http.ResponseWriter, r *http.Request) {
-	title := chi.URLParam(r, "title")
-	if title == "" {
+	key := movieKey(r)
+	if key == "" {
 		writeError(w, "BAD_REQUEST", "Missing title parameter", http.StatusBadRequest)
 		return
 	}
@@ -258,7 +754,7 @@ func (h *RatingHandler) SubmitRating(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	movie, err := h.movieStore.GetByTitle(r.Context(), title)
+	movie, err := resolveMovie(r.Context(), h.movieStore, key)
 	if err != nil {
 		h.logger.Error("failed to get movie", "err", err)
 		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
@@ -289,7 +785,8 @@ func (h *RatingHandler) SubmitRating(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := map[string]interface{}{
-		"movieTitle": title,
+		"movieId":    movie.ID,
+		"movieTitle": movie.Title,
 		"raterId":    raterID,
 		"rating":     req.Rating,
 	}
@@ -300,20 +797,21 @@ func (h *RatingHandler) SubmitRating(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Location", buildAbsoluteURL(r, fmt.Sprintf("/movies/%s/ratings", title)))
+	setCanonical(w, r, movie)
+	w.Header().Set("Location", buildAbsoluteURL(r, fmt.Sprintf("/movies/%s/ratings", movie.ID)))
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
 // GetAggregate handles GET /movies/{title}/rating.
 func (h *RatingHandler) GetAggregate(w http.ResponseWriter, r *http.Request) {
-	title := chi.URLParam(r, "title")
-	if title == "" {
+	key := movieKey(r)
+	if key == "" {
 		writeError(w, "BAD_REQUEST", "Missing title parameter", http.StatusBadRequest)
 		return
 	}
 
-	movie, err := h.movieStore.GetByTitle(r.Context(), title)
+	movie, err := resolveMovie(r.Context(), h.movieStore, key)
 	if err != nil {
 		h.logger.Error("failed to get movie", "err", err)
 		writeError(w, "INTERNAL_ERROR", "Failed to get movie", http.StatusInternalServerError)
@@ -331,6 +829,7 @@ func (h *RatingHandler) GetAggregate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Link", canonicalLink(r, movie))
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(agg)
 }
@@ -345,6 +844,355 @@ func isValidRating(r float64) bool {
 	return false
 }
 
+// movieKey returns the decoded {title} path segment, which may hold a title or a ULID.
+// chi matches against the raw path when it contains escapes such as %2F, so the
+// parameter has to be unescaped in that case.
+func movieKey(r *http.Request) string {
+	key := chi.URLParam(r, "title")
+	if r.URL.RawPath != "" {
+		if unescaped, err := url.PathUnescape(key); err == nil {
+			return unescaped
+		}
+	}
+	return key
+}
+
+// resolveMovie looks a movie up by ID when key is a valid ULID and by title otherwise.
+// A ULID-shaped key that matches no ID still falls back to a title lookup.
+func resolveMovie(ctx context.Context, ms *store.MovieStore, key string) (*store.Movie, error) {
+	if _, err := ulid.ParseStrict(key); err == nil {
+		movie, err := ms.GetByID(ctx, key)
+		if err != nil || movie != nil {
+			return movie, err
+		}
+	}
+	return ms.GetByTitle(ctx, key)
+}
+
+// canonicalPath returns the stable, ID-based path of a movie.
+func canonicalPath(m *store.Movie) string {
+	return "/movies/" + m.ID
+}
+
+func canonicalLink(r *http.Request, m *store.Movie) string {
+	return fmt.Sprintf(`<%s>; rel="canonical"`, buildAbsoluteURL(r, canonicalPath(m)))
+}
+
+// setCanonical records the movie's canonical URL on the body and in a Link header.
+func setCanonical(w http.ResponseWriter, r *http.Request, m *store.Movie) {
+	m.CanonicalURL = buildAbsoluteURL(r, canonicalPath(m))
+	w.Header().Set("Link", canonicalLink(r, m))
+}
+
+// movieETag derives a strong entity tag from a movie's MovieStore.LastModified
+// time.
+func movieETag(modified time.Time) string {
+	return fmt.Sprintf(`"%x"`, modified.UnixMicro())
+}
+
+// setValidators writes the ETag and Last-Modified headers for a movie last
+// modified at modified.
+func setValidators(w http.ResponseWriter, modified time.Time) {
+	w.Header().Set("ETag", movieETag(modified))
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+}
+
+// ifMatch reports whether the request's If-Match header, if any, matches etag.
+// If-Match uses strong comparison, so weak tags never match.
+func ifMatch(r *http.Request, etag string) bool {
+	im := r.Header.Get("If-Match")
+	if im == "" {
+		return true
+	}
+	for _, candidate := range strings.Split(im, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedVersion returns the timestamp the write must still observe when the
+// client asked for optimistic concurrency through If-Match.
+func expectedVersion(r *http.Request, current *store.Movie) *time.Time {
+	if r.Header.Get("If-Match") == "" {
+		return nil
+	}
+	return &current.UpdatedAt
+}
+
+// notModified evaluates If-None-Match and, when absent, If-Modified-Since.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// parseListFilters validates the GET /movies query parameters. Cursors must be
+// signed with cursorKey.
+func parseListFilters(q url.Values, cursorKey []byte) (store.ListFilters, error) {
+	releaseDate, err := parseReleaseDateRange(q)
+	if err != nil {
+		return store.ListFilters{}, err
+	}
+
+	limit := 20
+	if lStr := q.Get("limit"); lStr != "" {
+		l, err := strconv.Atoi(lStr)
+		if err != nil || l < 1 {
+			return store.ListFilters{}, errors.New("invalid limit parameter")
+		}
+		limit = l
+	}
+
+	query := strings.TrimSpace(q.Get("q"))
+	sort := store.DefaultSort(query)
+	if sStr := q.Get("sort"); sStr != "" {
+		if !store.ValidSort(sStr) {
+			return store.ListFilters{}, errors.New("invalid sort parameter")
+		}
+		sort = sStr
+	}
+	if sort == store.SortRelevance && query == "" {
+		return store.ListFilters{}, errors.New("sort=relevance requires the q parameter")
+	}
+
+	desc := store.DefaultDesc(sort)
+	switch q.Get("order") {
+	case "":
+	case "asc":
+		desc = false
+	case "desc":
+		desc = true
+	default:
+		return store.ListFilters{}, errors.New("invalid order parameter")
+	}
+
+	var cursor *store.Cursor
+	if cStr := q.Get("cursor"); cStr != "" {
+		c, err := store.DecodeCursor(cStr, cursorKey)
+		if err != nil {
+			return store.ListFilters{}, errors.New("invalid cursor parameter")
+		}
+		if c.Sort != sort || c.Desc != desc {
+			return store.ListFilters{}, errors.New("cursor does not match sort and order parameters")
+		}
+		cursor = c
+	}
+
+	filters := store.ListFilters{
+		Query:       query,
+		ReleaseDate: releaseDate,
+		Genre:       valueSet(q, "genre", splitList),
+		Distributor: valueSet(q, "distributor", repeatedList),
+		MPARating:   valueSet(q, "mpaRating", splitList),
+		Sort:        sort,
+		Desc:        desc,
+		Limit:       limit,
+		Cursor:      cursor,
+	}
+	ranges := []struct {
+		name string
+		dst  *store.Int64Range
+	}{
+		{"budget", &filters.Budget},
+		{"gross", &filters.WorldwideGross},
+		{"domesticGross", &filters.DomesticGross},
+		{"internationalGross", &filters.InternationalGross},
+		{"theaterCount", &filters.TheaterCount},
+		{"weeksInRelease", &filters.WeeksInRelease},
+	}
+	for _, rng := range ranges {
+		if *rng.dst, err = parseRange(q, rng.name); err != nil {
+			return store.ListFilters{}, err
+		}
+	}
+
+	// budget is the original "at most" filter and narrows budgetMax.
+	if bStr := q.Get("budget"); bStr != "" {
+		b, err := strconv.ParseInt(bStr, 10, 64)
+		if err != nil {
+			return store.ListFilters{}, errors.New("invalid budget parameter")
+		}
+		if filters.Budget.Max == nil || b < *filters.Budget.Max {
+			filters.Budget.Max = &b
+		}
+	}
+
+	if v := q.Get("minAverageRating"); v != "" {
+		avg, err := strconv.ParseFloat(v, 64)
+		if err != nil || avg < 0 || avg > 5 {
+			return store.ListFilters{}, errors.New("invalid minAverageRating parameter")
+		}
+		filters.MinAverageRating = &avg
+	}
+	if v := q.Get("minRatingCount"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return store.ListFilters{}, errors.New("invalid minRatingCount parameter")
+		}
+		filters.MinRatingCount = &n
+	}
+
+	for _, inc := range splitList(q["include"]) {
+		switch inc {
+		case "rating", "fieldSources":
+			// Applied through the projection and when rendering the response.
+		default:
+			return store.ListFilters{}, errors.New("invalid include parameter")
+		}
+	}
+
+	proj, err := parseProjection(q)
+	if err != nil {
+		return store.ListFilters{}, err
+	}
+	filters.Fields = proj.storeFields()
+	filters.IncludeRating = proj.rating
+	filters.IncludeBoxOfficeHistory = proj.history
+	return filters, nil
+}
+
+// parseReleaseDateRange combines year, yearFrom/yearTo and
+// releasedAfter/releasedBefore into one date range; all given bounds apply.
+// releasedAfter and releasedBefore are exclusive.
+func parseReleaseDateRange(q url.Values) (store.DateRange, error) {
+	var rng store.DateRange
+
+	year := func(param string) (*int, error) {
+		v := q.Get(param)
+		if v == "" {
+			return nil, nil
+		}
+		y, err := strconv.Atoi(v)
+		if err != nil || y < 1 || y > 9999 {
+			return nil, fmt.Errorf("invalid %s parameter", param)
+		}
+		return &y, nil
+	}
+	startOf := func(y int) *time.Time {
+		t := time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	for _, param := range []string{"year", "yearFrom", "yearTo"} {
+		y, err := year(param)
+		if err != nil {
+			return store.DateRange{}, err
+		}
+		if y == nil {
+			continue
+		}
+		switch param {
+		case "year":
+			rng = rng.Narrow(startOf(*y), startOf(*y+1))
+		case "yearFrom":
+			rng = rng.Narrow(startOf(*y), nil)
+		case "yearTo":
+			rng = rng.Narrow(nil, startOf(*y+1))
+		}
+	}
+
+	for _, param := range []string{"releasedAfter", "releasedBefore"} {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return store.DateRange{}, fmt.Errorf("invalid %s parameter", param)
+		}
+		if param == "releasedAfter" {
+			next := d.AddDate(0, 0, 1)
+			rng = rng.Narrow(&next, nil)
+		} else {
+			rng = rng.Narrow(nil, &d)
+		}
+	}
+
+	if rng.From != nil && rng.Before != nil && !rng.From.Before(*rng.Before) {
+		return store.DateRange{}, errors.New("release date filters select an empty range")
+	}
+	return rng, nil
+}
+
+// valueSet reads a multi-value filter: name=value parameters select any of the
+// values, and name!=value parameters (sent as the key "name!") exclude them.
+// list turns the raw parameters into values: splitList for enum-like fields,
+// whose values never contain commas, and repeatedList for free text such as
+// distributor names ("Sony Pictures Releasing, Inc.").
+func valueSet(q url.Values, name string, list func([]string) []string) store.ValueSet {
+	return store.ValueSet{In: list(q[name]), NotIn: list(q[name+"!"])}
+}
+
+// parseRange reads the <name>Min and <name>Max query parameters.
+func parseRange(q url.Values, name string) (store.Int64Range, error) {
+	var rng store.Int64Range
+	for _, bound := range []struct {
+		param string
+		dst   **int64
+	}{
+		{name + "Min", &rng.Min},
+		{name + "Max", &rng.Max},
+	} {
+		v := q.Get(bound.param)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return store.Int64Range{}, fmt.Errorf("invalid %s parameter", bound.param)
+		}
+		*bound.dst = &n
+	}
+	if rng.Min != nil && rng.Max != nil && *rng.Min > *rng.Max {
+		return store.Int64Range{}, fmt.Errorf("%sMin must not exceed %sMax", name, name)
+	}
+	return rng, nil
+}
+
+// splitList flattens repeated and comma-separated query values, dropping blanks.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// repeatedList collects one value per repeated query parameter, trimming
+// spaces and dropping blanks; commas are part of the value.
+func repeatedList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // buildAbsoluteURL constructs an absolute URL from the request.
 func buildAbsoluteURL(r *http.Request, path string) string {
 	scheme := "http"
@@ -364,15 +1212,27 @@ func writeError(w http.ResponseWriter, code, message string, status int) {
 	_ = json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
 }
 
-// HealthCheck handles GET /healthz.
-func HealthCheck(db *store.DB) http.HandlerFunc {
+// HealthCheck handles GET /healthz. The service is unhealthy only when the
+// database is unreachable; an open box office breaker reports "degraded"
+// because movies can still be created without enrichment.
+func HealthCheck(db *store.DB, bo *boxoffice.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		breaker := bo.BreakerState()
+		resp := map[string]interface{}{
+			"status":    "ok",
+			"boxOffice": map[string]string{"breaker": string(breaker)},
+		}
+
+		status := http.StatusOK
 		if err := db.Ping(r.Context()); err != nil {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			fmt.Fprint(w, "unhealthy")
-			return
+			resp["status"] = "unhealthy"
+			status = http.StatusServiceUnavailable
+		} else if breaker != boxoffice.BreakerClosed {
+			resp["status"] = "degraded"
 		}
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprint(w, "ok")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
 	}
 }
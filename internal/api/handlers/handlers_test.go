@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples from RFC 7396, Appendix A, that have an object target and
+	// patch, plus the nested objects a movie patch touches.
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"a":"b"}`, `{"a":{"b":"c"}}`, `{"a":{"b":"c"}}`},
+		{`{"title":"Alien","budget":11000000}`, `{"budget":null,"mpaRating":"R"}`, `{"title":"Alien","mpaRating":"R"}`},
+		{`{"title":"Alien"}`, `{}`, `{"title":"Alien"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+" + "+tt.patch, func(t *testing.T) {
+			var target, patch, want map[string]interface{}
+			for _, doc := range []struct {
+				src string
+				dst *map[string]interface{}
+			}{{tt.target, &target}, {tt.patch, &patch}, {tt.want, &want}} {
+				if err := json.Unmarshal([]byte(doc.src), doc.dst); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := mergePatch(target, patch); !reflect.DeepEqual(got, want) {
+				t.Errorf("mergePatch = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMergePatchNilTarget(t *testing.T) {
+	got := mergePatch(nil, map[string]interface{}{"a": "b", "c": nil})
+	if want := map[string]interface{}{"a": "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mergePatch(nil) = %v, want %v", got, want)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	etag := movieETag(time.UnixMicro(1700000000123456))
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"absent", "", true},
+		{"exact", etag, true},
+		{"wildcard", "*", true},
+		{"in list", `"other", ` + etag, true},
+		{"stale", `"5f5e100"`, false},
+		{"weak", "W/" + etag, false},
+		{"unquoted", etag[1 : len(etag)-1], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/movies/x", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			if got := ifMatch(r, etag); got != tt.want {
+				t.Errorf("ifMatch(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 500000000, time.UTC)
+	etag := movieETag(modified)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"no validators", nil, false},
+		{"matching etag", map[string]string{"If-None-Match": etag}, true},
+		{"weak matching etag", map[string]string{"If-None-Match": "W/" + etag}, true},
+		{"other etag", map[string]string{"If-None-Match": `"1"`}, false},
+		{"etag wins over date", map[string]string{"If-None-Match": `"1"`, "If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, false},
+		{"same second", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, false},
+		{"bad date", map[string]string{"If-Modified-Since": "yesterday"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/movies/x", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := notModified(r, etag, modified); got != tt.want {
+				t.Errorf("notModified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/robin-camp/movies/internal/search"
+	"github.com/robin-camp/movies/internal/store"
+)
+
+// importBatchSize is how many valid rows go into one multi-row INSERT and
+// transaction.
+const importBatchSize = 500
+
+// maxImportLine bounds one NDJSON line.
+const maxImportLine = 1 << 20
+
+// Import row outcomes.
+const (
+	importCreated   = "created"
+	importDuplicate = "duplicate"
+	importInvalid   = "invalid"
+)
+
+// importColumns are the CSV header names, matching the CreateRequest fields.
+var importColumns = []string{"title", "genre", "releaseDate", "distributor", "budget", "mpaRating"}
+
+// ImportResult reports what happened to one input row. Line is the row's line
+// in the input.
+type ImportResult struct {
+	Line   int    `json:"line"`
+	Title  string `json:"title,omitempty"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// importRow is one parsed input row; err is set when it could not be parsed.
+type importRow struct {
+	line int
+	req  CreateRequest
+	err  error
+}
+
+// Import handles POST /movies:import. The body is NDJSON (one CreateRequest per
+// line) or CSV with a header row, validated row by row like POST /movies.
+// Valid rows are stored in batches, one transaction each, with their
+// enrichment queued for the background workers unless enrich=none. Batches
+// committed before a database failure stay stored.
+func (h *MovieHandler) Import(w http.ResponseWriter, r *http.Request) {
+	queueJobs := true
+	switch r.URL.Query().Get("enrich") {
+	case "", "deferred":
+	case "none":
+		queueJobs = false
+	default:
+		writeError(w, "BAD_REQUEST", "enrich must be none or deferred", http.StatusBadRequest)
+		return
+	}
+
+	// Large catalogues take longer than the server's default timeouts.
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var next func() (*importRow, error)
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		next = ndjsonRows(r.Body)
+	case "text/csv":
+		var err error
+		if next, err = csvRows(r.Body); err != nil {
+			writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		writeError(w, "UNSUPPORTED_MEDIA_TYPE", "Content-Type must be application/x-ndjson or text/csv", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var results []ImportResult
+	var batch []*store.Movie
+	var batchIdx []int
+	seen := make(map[string]bool)
+	created := 0
+
+	flush := func() error {
+		outcomes, err := h.movieStore.CreateMany(r.Context(), batch, queueJobs)
+		if err != nil {
+			return err
+		}
+		for i, outcome := range outcomes {
+			res := &results[batchIdx[i]]
+			var rowErr *store.RowError
+			switch {
+			case outcome == nil:
+				res.Status, res.ID = importCreated, batch[i].ID
+				h.titles.Put(batch[i].Suggestion())
+				created++
+			case errors.Is(outcome, store.ErrDuplicateTitle):
+				res.Status, res.Reason = importDuplicate, "title already exists"
+			case errors.As(outcome, &rowErr):
+				res.Status, res.Reason = importInvalid, rowErr.Message
+			}
+		}
+		batch, batchIdx = batch[:0], batchIdx[:0]
+		return nil
+	}
+
+	for {
+		row, err := next()
+		if err != nil {
+			msg := err.Error()
+			if created > 0 {
+				msg += fmt.Sprintf("; %d movies were created before it", created)
+			}
+			writeError(w, "BAD_REQUEST", msg, http.StatusBadRequest)
+			return
+		}
+		if row == nil {
+			break
+		}
+
+		res, movie := prepareRow(row, seen)
+		if movie != nil {
+			batch = append(batch, movie)
+			batchIdx = append(batchIdx, len(results))
+		}
+		results = append(results, res)
+
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				h.writeImportFailure(w, err, created)
+				return
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			h.writeImportFailure(w, err, created)
+			return
+		}
+	}
+
+	summary := map[string]int{importCreated: 0, importDuplicate: 0, importInvalid: 0}
+	for _, res := range results {
+		summary[res.Status]++
+	}
+	if results == nil {
+		results = []ImportResult{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"summary": summary, "results": results})
+}
+
+func (h *MovieHandler) writeImportFailure(w http.ResponseWriter, err error, created int) {
+	h.logger.Error("movie import failed", "err", err, "created", created)
+	writeError(w, "INTERNAL_ERROR",
+		fmt.Sprintf("Import failed; %d movies were created before the failure", created),
+		http.StatusInternalServerError)
+}
+
+// prepareRow starts the report for row. It returns the movie to store when
+// the row is valid and its title was not seen earlier in the import; the
+// result's status is then left for the store outcome to fill in.
+func prepareRow(row *importRow, seen map[string]bool) (ImportResult, *store.Movie) {
+	res := ImportResult{Line: row.line, Title: row.req.Title}
+	movie, err := importMovie(row)
+	switch {
+	case err != nil:
+		res.Status, res.Reason = importInvalid, err.Error()
+		return res, nil
+	case seen[search.Fold(movie.Title)]:
+		res.Status, res.Reason = importDuplicate, "title repeated earlier in the import"
+		return res, nil
+	}
+	seen[search.Fold(movie.Title)] = true
+	return res, movie
+}
+
+// importMovie validates a row like POST /movies and builds the movie to store.
+func importMovie(row *importRow) (*store.Movie, error) {
+	if row.err != nil {
+		return nil, row.err
+	}
+	releaseDate, err := row.req.validate()
+	if err != nil {
+		return nil, err
+	}
+	movie := &store.Movie{
+		ID:          ulid.Make().String(),
+		Title:       row.req.Title,
+		ReleaseDate: releaseDate,
+		Genre:       row.req.Genre,
+		Distributor: row.req.Distributor,
+		Budget:      row.req.Budget,
+		MPARating:   row.req.MPARating,
+	}
+	movie.FieldSources = userSources(nil, &row.req, allFields)
+	return movie, nil
+}
+
+// ndjsonRows reads one CreateRequest per non-blank line. A row that is not
+// valid JSON is returned with its error rather than ending the import.
+func ndjsonRows(body io.Reader) func() (*importRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+	line := 0
+	return func() (*importRow, error) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			row := &importRow{line: line}
+			if err := json.Unmarshal([]byte(text), &row.req); err != nil {
+				row.err = errors.New("invalid JSON")
+			}
+			return row, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read line %d: %w", line+1, err)
+		}
+		return nil, nil
+	}
+}
+
+// csvRows reads CSV rows keyed by a header naming importColumns; title, genre
+// and releaseDate are required columns and empty optional cells are omitted.
+func csvRows(body io.Reader) (func() (*importRow, error), error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("CSV input must start with a header row")
+	}
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if !slices.Contains(importColumns, header[i]) {
+			return nil, fmt.Errorf("unknown CSV column %q", header[i])
+		}
+		if slices.Contains(header[:i], header[i]) {
+			return nil, fmt.Errorf("duplicate CSV column %q", header[i])
+		}
+	}
+	for _, required := range []string{"title", "genre", "releaseDate"} {
+		if !slices.Contains(header, required) {
+			return nil, fmt.Errorf("CSV header is missing the %s column", required)
+		}
+	}
+
+	return func() (*importRow, error) {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return &importRow{line: parseErr.StartLine, err: errors.New("malformed CSV record")}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &importRow{line: line}
+		if len(record) != len(header) {
+			row.err = fmt.Errorf("expected %d fields, got %d", len(header), len(record))
+			return row, nil
+		}
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			switch header[i] {
+			case "title":
+				row.req.Title = value
+			case "genre":
+				row.req.Genre = value
+			case "releaseDate":
+				row.req.ReleaseDate = value
+			case "distributor":
+				if value != "" {
+					row.req.Distributor = &value
+				}
+			case "mpaRating":
+				if value != "" {
+					row.req.MPARating = &value
+				}
+			case "budget":
+				if value == "" {
+					continue
+				}
+				budget, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					row.err = errors.New("budget must be an integer")
+					continue
+				}
+				row.req.Budget = &budget
+			}
+		}
+		return row, nil
+	}, nil
+}
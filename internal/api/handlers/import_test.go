@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// drain reads every row from next.
+func drain(t *testing.T, next func() (*importRow, error)) []*importRow {
+	t.Helper()
+	var rows []*importRow
+	for {
+		row, err := next()
+		if err != nil {
+			t.Fatalf("reading rows: %v", err)
+		}
+		if row == nil {
+			return rows
+		}
+		rows = append(rows, row)
+	}
+}
+
+func strp(s string) *string { return &s }
+
+func int64p(n int64) *int64 { return &n }
+
+func TestCSVRows(t *testing.T) {
+	type want struct {
+		line int
+		req  CreateRequest
+		err  string
+	}
+	tests := []struct {
+		name  string
+		input string
+		rows  []want
+	}{
+		{
+			name:  "all columns",
+			input: "title,genre,releaseDate,distributor,budget,mpaRating\nAlien,Horror,1979-05-25,20th Century Fox,11000000,R\n",
+			rows: []want{{line: 2, req: CreateRequest{
+				Title: "Alien", Genre: "Horror", ReleaseDate: "1979-05-25",
+				Distributor: strp("20th Century Fox"), Budget: int64p(11000000), MPARating: strp("R"),
+			}}},
+		},
+		{
+			name:  "columns in any order, cells trimmed, empty optionals omitted",
+			input: "releaseDate, title ,genre,budget,distributor\n 1995-12-15 ,  Heat,Crime,,\n",
+			rows:  []want{{line: 2, req: CreateRequest{Title: "Heat", Genre: "Crime", ReleaseDate: "1995-12-15"}}},
+		},
+		{
+			name:  "quoted commas and newlines",
+			input: "title,genre,releaseDate,distributor\n\"Crouching Tiger, Hidden Dragon\",Action,2000-07-06,\"Sony, Columbia\"\n\"Two\nLines\",Drama,2001-01-01,\nNext,Drama,2002-02-02,\n",
+			rows: []want{
+				{line: 2, req: CreateRequest{Title: "Crouching Tiger, Hidden Dragon", Genre: "Action", ReleaseDate: "2000-07-06", Distributor: strp("Sony, Columbia")}},
+				{line: 3, req: CreateRequest{Title: "Two\nLines", Genre: "Drama", ReleaseDate: "2001-01-01"}},
+				{line: 5, req: CreateRequest{Title: "Next", Genre: "Drama", ReleaseDate: "2002-02-02"}},
+			},
+		},
+		{
+			name:  "row errors do not stop the import",
+			input: "title,genre,releaseDate,budget\nA,Drama,2020-01-01,lots\nB,Drama\nC \"quoted\",Drama,2020-01-01,\nD,Drama,2020-01-01,5\n",
+			rows: []want{
+				{line: 2, req: CreateRequest{Title: "A", Genre: "Drama", ReleaseDate: "2020-01-01"}, err: "budget must be an integer"},
+				{line: 3, err: "expected 4 fields, got 2"},
+				{line: 4, err: "malformed CSV record"},
+				{line: 5, req: CreateRequest{Title: "D", Genre: "Drama", ReleaseDate: "2020-01-01", Budget: int64p(5)}},
+			},
+		},
+		{
+			name:  "header only",
+			input: "title,genre,releaseDate\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := csvRows(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("csvRows: %v", err)
+			}
+			rows := drain(t, next)
+			if len(rows) != len(tt.rows) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.rows))
+			}
+			for i, row := range rows {
+				w := tt.rows[i]
+				if row.line != w.line {
+					t.Errorf("row %d: line = %d, want %d", i, row.line, w.line)
+				}
+				gotErr := ""
+				if row.err != nil {
+					gotErr = row.err.Error()
+				}
+				if gotErr != w.err {
+					t.Errorf("row %d: err = %q, want %q", i, gotErr, w.err)
+				}
+				if w.err == "" && !reflect.DeepEqual(row.req, w.req) {
+					t.Errorf("row %d: req = %+v, want %+v", i, row.req, w.req)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVRowsHeader(t *testing.T) {
+	tests := []struct {
+		name, input, err string
+	}{
+		{"empty body", "", "CSV input must start with a header row"},
+		{"unknown column", "title,genre,releaseDate,rating\n", `unknown CSV column "rating"`},
+		{"missing required column", "title,releaseDate\n", "CSV header is missing the genre column"},
+		{"duplicate column", "title,genre,releaseDate, genre\n", `duplicate CSV column "genre"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := csvRows(strings.NewReader(tt.input))
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("csvRows error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNDJSONRows(t *testing.T) {
+	input := `{"title":"Alien","genre":"Horror","releaseDate":"1979-05-25","budget":11000000}
+
+{"title": "Heat"
+  {"title":"Aliens","genre":"Action","releaseDate":"1986-07-18"}
+`
+	rows := drain(t, ndjsonRows(strings.NewReader(input)))
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+	if rows[0].line != 1 || rows[0].err != nil || rows[0].req.Title != "Alien" || *rows[0].req.Budget != 11000000 {
+		t.Errorf("row 0 = %+v", rows[0])
+	}
+	if rows[1].line != 3 || rows[1].err == nil || rows[1].err.Error() != "invalid JSON" {
+		t.Errorf("row 1 = %+v, want invalid JSON on line 3", rows[1])
+	}
+	if rows[2].line != 4 || rows[2].err != nil || rows[2].req.Title != "Aliens" {
+		t.Errorf("row 2 = %+v", rows[2])
+	}
+}
+
+func TestNDJSONRowsLineTooLong(t *testing.T) {
+	input := `{"title":"` + strings.Repeat("a", maxImportLine) + `"}` + "\n"
+	row, err := ndjsonRows(strings.NewReader(input))()
+	if row != nil || err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("got %+v, %v, want an error naming line 1", row, err)
+	}
+}
+
+func TestImportReport(t *testing.T) {
+	input := "title,genre,releaseDate,budget\n" +
+		"Amélie,Romance,2001-04-25,\n" +
+		"Heat,Crime,12/15/1995,\n" +
+		",Drama,2020-01-01,\n" +
+		"AMELIE,Romance,2001-04-25,\n" +
+		"Heat,Crime,1995-12-15,60000000\n" +
+		"Broken,Drama,2020-01-01,1e6\n"
+	next, err := csvRows(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ImportResult{
+		{Line: 2, Title: "Amélie"},
+		{Line: 3, Title: "Heat", Status: importInvalid, Reason: "releaseDate must be in YYYY-MM-DD format"},
+		{Line: 4, Status: importInvalid, Reason: "title, genre, and releaseDate are required"},
+		{Line: 5, Title: "AMELIE", Status: importDuplicate, Reason: "title repeated earlier in the import"},
+		{Line: 6, Title: "Heat"},
+		{Line: 7, Title: "Broken", Status: importInvalid, Reason: "budget must be an integer"},
+	}
+	wantStored := []string{"Amélie", "Heat"}
+
+	seen := make(map[string]bool)
+	var results []ImportResult
+	var stored []string
+	for _, row := range drain(t, next) {
+		res, movie := prepareRow(row, seen)
+		results = append(results, res)
+		if movie == nil {
+			continue
+		}
+		stored = append(stored, movie.Title)
+		if movie.ID == "" || movie.ReleaseDate.IsZero() {
+			t.Errorf("movie for line %d lacks an ID or release date: %+v", row.line, movie)
+		}
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results =\n%+v\nwant\n%+v", results, want)
+	}
+	if !reflect.DeepEqual(stored, wantStored) {
+		t.Errorf("stored %v, want %v", stored, wantStored)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/robin-camp/movies/internal/store"
+)
+
+// apiFields are movie fields the API layer sets rather than reads from the store.
+var apiFields = []string{"canonicalUrl", "score"}
+
+// Related data the expand parameter can embed in movie responses.
+const (
+	expandRating           = "rating"
+	expandBoxOfficeHistory = "boxOfficeHistory"
+)
+
+// projection is the response shape requested through the fields and expand
+// query parameters.
+type projection struct {
+	fields  []string // nil renders every field
+	rating  bool
+	history bool
+}
+
+// parseProjection reads fields and expand. The older include=rating also
+// expands the rating.
+func parseProjection(q url.Values) (projection, error) {
+	var p projection
+	if q.Has("fields") {
+		p.fields = []string{}
+		for _, name := range splitList(q["fields"]) {
+			if !store.ValidField(name) && !slices.Contains(apiFields, name) {
+				return projection{}, fmt.Errorf("invalid field %q", name)
+			}
+			if !slices.Contains(p.fields, name) {
+				p.fields = append(p.fields, name)
+			}
+		}
+		if len(p.fields) == 0 {
+			return projection{}, errors.New("fields must name at least one field")
+		}
+	}
+
+	for _, name := range splitList(q["expand"]) {
+		switch name {
+		case expandRating:
+			p.rating = true
+		case expandBoxOfficeHistory:
+			p.history = true
+		default:
+			return projection{}, fmt.Errorf("invalid expand %q", name)
+		}
+	}
+	p.rating = p.rating || slices.Contains(splitList(q["include"]), expandRating)
+	return p, nil
+}
+
+// parseFullFilters parses the GET /movies filters for endpoints that work on
+// whole movies rather than rendering them, where a projection would leave
+// movies partially read. fields and expand are rejected.
+func parseFullFilters(q url.Values, cursorKey []byte) (store.ListFilters, error) {
+	for _, param := range []string{"fields", "expand"} {
+		if q.Has(param) {
+			return store.ListFilters{}, fmt.Errorf("%s is not supported here", param)
+		}
+	}
+	filters, err := parseListFilters(q, cursorKey)
+	if err != nil {
+		return store.ListFilters{}, err
+	}
+	filters.Fields, filters.IncludeRating, filters.IncludeBoxOfficeHistory = nil, false, false
+	return filters, nil
+}
+
+// storeFields returns the fields the store must read, nil for all of them.
+func (p projection) storeFields() []string {
+	if p.fields == nil {
+		return nil
+	}
+	fields := []string{}
+	for _, name := range p.fields {
+		if store.ValidField(name) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// render returns m as the client asked for it: the whole movie, or an object
+// with only the requested fields, the ID and the expansions.
+func (p projection) render(m *store.Movie) (interface{}, error) {
+	if p.fields == nil {
+		return m, nil
+	}
+	doc, err := toDocument(m)
+	if err != nil {
+		return nil, err
+	}
+	for key := range doc {
+		keep := key == "id" || slices.Contains(p.fields, key) ||
+			(key == expandRating && p.rating) || (key == expandBoxOfficeHistory && p.history)
+		if !keep {
+			delete(doc, key)
+		}
+	}
+	return doc, nil
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/robin-camp/movies/internal/store"
+)
+
+// errUnsupportedCurrency is returned when no rates exist for a requested currency.
+var errUnsupportedCurrency = errors.New("unsupported currency")
+
+// ExchangeRateHandler handles the exchange rate admin endpoints.
+type ExchangeRateHandler struct {
+	rateStore *store.ExchangeRateStore
+	logger    *slog.Logger
+}
+
+// NewExchangeRateHandler creates an ExchangeRateHandler.
+func NewExchangeRateHandler(xs *store.ExchangeRateStore, logger *slog.Logger) *ExchangeRateHandler {
+	return &ExchangeRateHandler{rateStore: xs, logger: logger}
+}
+
+// exchangeRateResponse is the API representation of a stored rate.
+type exchangeRateResponse struct {
+	Currency  string     `json:"currency"`
+	Date      string     `json:"date"`
+	Rate      float64    `json:"rate"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+func toExchangeRateResponse(r store.ExchangeRate) exchangeRateResponse {
+	resp := exchangeRateResponse{Currency: r.Currency, Date: r.Date(), Rate: r.Rate}
+	if !r.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &r.UpdatedAt
+	}
+	return resp
+}
+
+// List handles GET /exchange-rates.
+func (h *ExchangeRateHandler) List(w http.ResponseWriter, r *http.Request) {
+	currency, err := parseCurrency(r.URL.Query())
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rates, err := h.rateStore.List(r.Context(), currency)
+	if err != nil {
+		h.logger.Error("failed to list exchange rates", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to list exchange rates", http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]exchangeRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		items = append(items, toExchangeRateResponse(rate))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"base": store.BaseCurrency, "items": items})
+}
+
+// Put handles PUT /exchange-rates/{currency}/{date}.
+func (h *ExchangeRateHandler) Put(w http.ResponseWriter, r *http.Request) {
+	currency, date, ok := rateKey(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Rate float64 `json:"rate"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Rate <= 0 {
+		writeError(w, "BAD_REQUEST", "rate must be positive", http.StatusBadRequest)
+		return
+	}
+
+	rate := store.ExchangeRate{Currency: currency, RateDate: date, Rate: req.Rate}
+	if err := h.rateStore.Upsert(r.Context(), &rate); err != nil {
+		h.logger.Error("failed to store exchange rate", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to store exchange rate", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toExchangeRateResponse(rate))
+}
+
+// Delete handles DELETE /exchange-rates/{currency}/{date}.
+func (h *ExchangeRateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	currency, date, ok := rateKey(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.rateStore.Delete(r.Context(), currency, date)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, "NOT_FOUND", "Exchange rate not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to delete exchange rate", "err", err)
+		writeError(w, "INTERNAL_ERROR", "Failed to delete exchange rate", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// rateKey parses the {currency} and {date} path segments, writing a 400 when
+// they are invalid. The base currency cannot be given a rate.
+func rateKey(w http.ResponseWriter, r *http.Request) (string, time.Time, bool) {
+	currency := strings.ToUpper(chi.URLParam(r, "currency"))
+	if !validCurrency(currency) {
+		writeError(w, "BAD_REQUEST", "invalid currency", http.StatusBadRequest)
+		return "", time.Time{}, false
+	}
+	if currency == store.BaseCurrency {
+		writeError(w, "BAD_REQUEST", store.BaseCurrency+" is the base currency", http.StatusBadRequest)
+		return "", time.Time{}, false
+	}
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		writeError(w, "BAD_REQUEST", "invalid date", http.StatusBadRequest)
+		return "", time.Time{}, false
+	}
+	return currency, date, true
+}
+
+// parseCurrency reads the optional currency query parameter as an upper-case
+// ISO 4217 code.
+func parseCurrency(q url.Values) (string, error) {
+	currency := strings.ToUpper(strings.TrimSpace(q.Get("currency")))
+	if currency != "" && !validCurrency(currency) {
+		return "", errors.New("invalid currency parameter")
+	}
+	return currency, nil
+}
+
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// rateTable loads the rates needed to convert from the given stored currencies
+// into currency. It returns errUnsupportedCurrency when currency has no rates.
+func rateTable(ctx context.Context, xs *store.ExchangeRateStore, currency string, from []string) (*store.RateTable, error) {
+	currencies := append([]string{currency}, from...)
+	table, err := xs.Table(ctx, currencies)
+	if err != nil {
+		return nil, err
+	}
+	if !table.Has(currency) {
+		return nil, errUnsupportedCurrency
+	}
+	return table, nil
+}
+
+// convertMovies converts the box office figures of movies, including any
+// expanded history, into currency. Figures in a currency without rates are left
+// as stored.
+func convertMovies(ctx context.Context, xs *store.ExchangeRateStore, currency string, movies ...*store.Movie) error {
+	var from []string
+	for _, m := range movies {
+		if m.BoxOffice != nil {
+			from = append(from, m.BoxOffice.Currency)
+		}
+		for _, snap := range m.BoxOfficeHistory {
+			from = append(from, snap.Currency)
+		}
+	}
+	table, err := rateTable(ctx, xs, currency, from)
+	if err != nil {
+		return err
+	}
+	for _, m := range movies {
+		table.ConvertBoxOffice(m.BoxOffice, currency)
+		for i := range m.BoxOfficeHistory {
+			table.ConvertSnapshot(&m.BoxOfficeHistory[i], currency)
+		}
+	}
+	return nil
+}
+
+// writeConversionError reports a failed currency conversion.
+func writeConversionError(w http.ResponseWriter, logger *slog.Logger, err error) {
+	if errors.Is(err, errUnsupportedCurrency) {
+		writeError(w, "BAD_REQUEST", "unsupported currency: no exchange rates available", http.StatusBadRequest)
+		return
+	}
+	logger.Error("failed to load exchange rates", "err", err)
+	writeError(w, "INTERNAL_ERROR", "Failed to convert currency", http.StatusInternalServerError)
+}
@@ -0,0 +1,120 @@
+package boxoffice
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of the upstream circuit breaker.
+type BreakerState string
+
+// Circuit breaker states.
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+var (
+	// ErrCircuitOpen is returned without calling the upstream while the breaker is open.
+	ErrCircuitOpen = errors.New("box office circuit breaker is open")
+	// ErrBulkheadFull is returned when too many upstream calls are already in flight.
+	ErrBulkheadFull = errors.New("box office concurrency limit reached")
+)
+
+// breaker is a consecutive-failure circuit breaker. After threshold failures it
+// opens and rejects calls for cooldown; then it lets a single probe through
+// (half-open) and closes on success or re-opens on failure.
+type breaker struct {
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	threshold int
+	cooldown  time.Duration
+	logger    *slog.Logger
+}
+
+func newBreaker(threshold int, cooldown time.Duration, logger *slog.Logger) *breaker {
+	return &breaker{state: BreakerClosed, threshold: threshold, cooldown: cooldown, logger: logger}
+}
+
+// allow reports whether a call may proceed.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.transition(BreakerHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != BreakerClosed {
+		b.transition(BreakerClosed)
+	}
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		if b.state != BreakerOpen {
+			b.transition(BreakerOpen)
+		}
+	}
+}
+
+// cancel releases a half-open probe slot without recording an outcome, e.g.
+// when the caller gave up before the upstream answered.
+func (b *breaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// transition must be called with mu held.
+func (b *breaker) transition(to BreakerState) {
+	from := b.state
+	b.state = to
+	if to == BreakerOpen {
+		b.logger.Warn("box office circuit breaker state changed", "from", from, "to", to, "failures", b.failures, "cooldown", b.cooldown)
+		return
+	}
+	b.logger.Info("box office circuit breaker state changed", "from", from, "to", to)
+}
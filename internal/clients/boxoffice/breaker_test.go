@@ -0,0 +1,88 @@
+package boxoffice
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	type step struct {
+		action string // allow, success, failure, cancel or elapse
+		err    error  // expected from allow
+		state  BreakerState
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"stays closed below threshold", []step{
+			{action: "allow", state: BreakerClosed},
+			{action: "failure", state: BreakerClosed},
+			{action: "failure", state: BreakerClosed},
+			{action: "success", state: BreakerClosed},
+			{action: "failure", state: BreakerClosed},
+			{action: "failure", state: BreakerClosed},
+			{action: "allow", state: BreakerClosed},
+		}},
+		{"opens at threshold", []step{
+			{action: "failure", state: BreakerClosed},
+			{action: "failure", state: BreakerClosed},
+			{action: "failure", state: BreakerOpen},
+			{action: "allow", err: ErrCircuitOpen, state: BreakerOpen},
+		}},
+		{"half-open probe closes on success", []step{
+			{action: "failure"}, {action: "failure"}, {action: "failure", state: BreakerOpen},
+			{action: "elapse", state: BreakerHalfOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			{action: "allow", err: ErrCircuitOpen, state: BreakerHalfOpen},
+			{action: "success", state: BreakerClosed},
+			{action: "allow", state: BreakerClosed},
+			{action: "failure", state: BreakerClosed},
+		}},
+		{"half-open probe reopens on failure", []step{
+			{action: "failure"}, {action: "failure"}, {action: "failure", state: BreakerOpen},
+			{action: "elapse", state: BreakerHalfOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			{action: "failure", state: BreakerOpen},
+			{action: "allow", err: ErrCircuitOpen, state: BreakerOpen},
+		}},
+		{"cancelled probe frees the slot", []step{
+			{action: "failure"}, {action: "failure"}, {action: "failure", state: BreakerOpen},
+			{action: "elapse", state: BreakerHalfOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			{action: "cancel", state: BreakerHalfOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			{action: "success", state: BreakerClosed},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(3, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			for i, s := range tt.steps {
+				switch s.action {
+				case "allow":
+					if err := b.allow(); err != s.err {
+						t.Fatalf("step %d: allow() = %v, want %v", i, err, s.err)
+					}
+				case "success":
+					b.success()
+				case "failure":
+					b.failure()
+				case "cancel":
+					b.cancel()
+				case "elapse":
+					b.mu.Lock()
+					b.openedAt = b.openedAt.Add(-b.cooldown)
+					b.mu.Unlock()
+				}
+				if s.state != "" {
+					if got := b.State(); got != s.state {
+						t.Fatalf("step %d (%s): state = %s, want %s", i, s.action, got, s.state)
+					}
+				}
+			}
+		})
+	}
+}
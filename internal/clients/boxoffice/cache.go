@@ -0,0 +1,250 @@
+package boxoffice
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Cache stores provider answers by key. A cached nil response records that the
+// provider had no data for the title.
+type Cache interface {
+	// Get returns the cached response and whether an unexpired entry exists.
+	Get(ctx context.Context, key string) (resp *Response, ok bool, err error)
+	// Set stores resp (nil for not found) for ttl.
+	Set(ctx context.Context, key string, resp *Response, ttl time.Duration) error
+}
+
+type bypassCacheKey struct{}
+
+// BypassCache returns a context under which CachedProvider skips its cached
+// entry, asks the wrapped provider and stores the fresh answer in its place.
+// Forced refreshes use it so they never return stale data.
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func bypassingCache(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// CacheOptions sets how long answers are kept; a zero TTL disables caching of
+// that kind of answer.
+type CacheOptions struct {
+	TTL         time.Duration // lifetime of responses with data
+	NotFoundTTL time.Duration // lifetime of "no data for this title" answers
+}
+
+// CachedProvider is a read-through cache in front of another provider. Errors
+// are never cached, and a failing cache backend degrades to calling the
+// provider directly.
+type CachedProvider struct {
+	next   Provider
+	cache  Cache
+	opts   CacheOptions
+	logger *slog.Logger
+}
+
+// NewCachedProvider wraps next with cache.
+func NewCachedProvider(next Provider, cache Cache, opts CacheOptions, logger *slog.Logger) *CachedProvider {
+	return &CachedProvider{next: next, cache: cache, opts: opts, logger: logger}
+}
+
+// Name reports the wrapped provider's name; caching does not change the source.
+func (p *CachedProvider) Name() string {
+	return p.next.Name()
+}
+
+// GetByTitle serves the title from the cache, falling back to the wrapped
+// provider. Under BypassCache the cache is only written.
+func (p *CachedProvider) GetByTitle(ctx context.Context, title string) (*Response, error) {
+	key := p.next.Name() + ":" + strings.ToLower(title)
+
+	if !bypassingCache(ctx) {
+		resp, ok, err := p.cache.Get(ctx, key)
+		if err != nil {
+			p.logger.Warn("box office cache read failed", "title", title, "err", err)
+		} else if ok {
+			return resp, nil
+		}
+	}
+
+	resp, err := p.next.GetByTitle(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := p.opts.TTL
+	if resp == nil {
+		ttl = p.opts.NotFoundTTL
+	}
+	if ttl > 0 {
+		if err := p.cache.Set(ctx, key, resp, ttl); err != nil {
+			p.logger.Warn("box office cache write failed", "title", title, "err", err)
+		}
+	}
+	return resp, nil
+}
+
+// MemoryCache is an in-process LRU cache bounded by entry count.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	resp    *Response
+	expires time.Time
+}
+
+// NewMemoryCache creates an LRU cache holding at most capacity entries.
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &MemoryCache{capacity: capacity, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(_ context.Context, key string) (*Response, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+	return copyResponse(entry.resp), true, nil
+}
+
+// Set implements Cache.
+func (c *MemoryCache) Set(_ context.Context, key string, resp *Response, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryEntry{key: key, resp: copyResponse(resp), expires: time.Now().Add(ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// copyResponse keeps callers from mutating cached values.
+func copyResponse(resp *Response) *Response {
+	if resp == nil {
+		return nil
+	}
+	cp := *resp
+	return &cp
+}
+
+// MySQLCache keeps entries in the box_office_cache table so they survive
+// restarts and are shared between replicas. Expired rows are overwritten on the
+// next lookup of the same title; Purge deletes the rest.
+type MySQLCache struct {
+	db *sqlx.DB
+}
+
+// NewMySQLCache creates a cache backed by the box_office_cache table.
+func NewMySQLCache(db *sqlx.DB) *MySQLCache {
+	return &MySQLCache{db: db}
+}
+
+// Get implements Cache.
+func (c *MySQLCache) Get(ctx context.Context, key string) (*Response, bool, error) {
+	var row struct {
+		Response []byte         `db:"response"`
+		Source   sql.NullString `db:"source"`
+	}
+	err := c.db.GetContext(ctx, &row,
+		`SELECT response, source FROM box_office_cache
+		WHERE cache_key = ? AND expires_at > CURRENT_TIMESTAMP(6)`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if row.Response == nil {
+		return nil, true, nil
+	}
+
+	var resp Response
+	if err := json.Unmarshal(row.Response, &resp); err != nil {
+		return nil, false, fmt.Errorf("decode cached response: %w", err)
+	}
+	resp.Source = row.Source.String
+	return &resp, true, nil
+}
+
+// Set implements Cache.
+func (c *MySQLCache) Set(ctx context.Context, key string, resp *Response, ttl time.Duration) error {
+	var data []byte
+	var source sql.NullString
+	if resp != nil {
+		var err error
+		if data, err = json.Marshal(resp); err != nil {
+			return err
+		}
+		source = sql.NullString{String: resp.Source, Valid: true}
+	}
+
+	_, err := c.db.ExecContext(ctx,
+		`INSERT INTO box_office_cache (cache_key, response, source, expires_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP(6) + INTERVAL ? MICROSECOND)
+		ON DUPLICATE KEY UPDATE response = VALUES(response), source = VALUES(source), expires_at = VALUES(expires_at)`,
+		key, data, source, ttl.Microseconds())
+	return err
+}
+
+// purgeBatch bounds how many rows one Purge statement deletes, keeping each
+// delete's locks short.
+const purgeBatch = 1000
+
+// Purge deletes expired entries and returns how many it removed.
+func (c *MySQLCache) Purge(ctx context.Context) (int64, error) {
+	var total int64
+	for {
+		res, err := c.db.ExecContext(ctx,
+			`DELETE FROM box_office_cache WHERE expires_at <= CURRENT_TIMESTAMP(6) LIMIT ?`, purgeBatch)
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < purgeBatch {
+			return total, nil
+		}
+	}
+}
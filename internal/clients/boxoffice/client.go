@@ -13,32 +13,71 @@ import (
 	"github.com/hashicorp/go-retryablehttp"
 )
 
-// Client wraps the external Box Office API.
+// Client wraps the external Box Office API. Calls go through a circuit breaker
+// and a concurrency bulkhead; wrap it in a CachedProvider to avoid repeating
+// lookups.
 type Client struct {
 	baseURL string
 	apiKey  string
 	client  *http.Client
 	logger  *slog.Logger
+	breaker *breaker
+	slots   chan struct{}
 }
 
-// Response represents the API response structure.
+// Options tunes the client's resilience settings; zero values select defaults.
+type Options struct {
+	BreakerThreshold int           // consecutive failures that open the breaker (default 5)
+	BreakerCooldown  time.Duration // how long the breaker stays open before probing (default 30s)
+	MaxConcurrency   int           // upstream calls allowed in flight (default 8)
+}
+
+func (o Options) withDefaults() Options {
+	if o.BreakerThreshold <= 0 {
+		o.BreakerThreshold = 5
+	}
+	if o.BreakerCooldown <= 0 {
+		o.BreakerCooldown = 30 * time.Second
+	}
+	if o.MaxConcurrency <= 0 {
+		o.MaxConcurrency = 8
+	}
+	return o
+}
+
+// Response represents the API response structure. Revenue is in Currency, or
+// USD when the source does not name one. Source names the provider that
+// produced it and LastReported is when the figures were current; providers
+// without their own timestamp use the time of the lookup.
+//
+// The revenue breakdown, theaterCount and weeksInRelease are optional; older
+// payloads without them decode with zero values, which mean "unknown".
 type Response struct {
-	Title       string  `json:"title"`
-	Distributor string  `json:"distributor"`
-	ReleaseDate string  `json:"releaseDate"`
-	Budget      int64   `json:"budget"`
-	Revenue     Revenue `json:"revenue"`
-	MPARating   string  `json:"mpaRating"`
+	Title          string    `json:"title"`
+	Distributor    string    `json:"distributor"`
+	ReleaseDate    string    `json:"releaseDate"`
+	Budget         int64     `json:"budget"`
+	Revenue        Revenue   `json:"revenue"`
+	Currency       string    `json:"currency,omitempty"`
+	TheaterCount   int       `json:"theaterCount,omitempty"`
+	WeeksInRelease int       `json:"weeksInRelease,omitempty"`
+	MPARating      string    `json:"mpaRating"`
+	LastReported   time.Time `json:"lastReported"`
+	Source         string    `json:"-"`
 }
 
 // Revenue represents box office revenue data.
 type Revenue struct {
 	Worldwide         int64 `json:"worldwide"`
+	Domestic          int64 `json:"domestic,omitempty"`
+	International     int64 `json:"international,omitempty"`
 	OpeningWeekendUSA int64 `json:"openingWeekendUSA"`
 }
 
 // NewClient creates a Box Office API client with retries.
-func NewClient(baseURL, apiKey string, logger *slog.Logger) *Client {
+func NewClient(baseURL, apiKey string, opts Options, logger *slog.Logger) *Client {
+	opts = opts.withDefaults()
+
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = 2
 	retryClient.RetryWaitMin = 100 * time.Millisecond
@@ -53,11 +92,51 @@ func NewClient(baseURL, apiKey string, logger *slog.Logger) *Client {
 		apiKey:  apiKey,
 		client:  stdClient,
 		logger:  logger,
+		breaker: newBreaker(opts.BreakerThreshold, opts.BreakerCooldown, logger),
+		slots:   make(chan struct{}, opts.MaxConcurrency),
 	}
 }
 
-// GetByTitle fetches box office data for a movie title.
+// Name identifies the Apifox-hosted box office API as a data source.
+func (c *Client) Name() string {
+	return "ExampleBoxOfficeAPI"
+}
+
+// BreakerState reports the current circuit breaker state.
+func (c *Client) BreakerState() BreakerState {
+	return c.breaker.State()
+}
+
+// GetByTitle fetches box office data for a movie title. It returns nil, nil when
+// the upstream has no data for the title, ErrCircuitOpen while the upstream is
+// considered down and ErrBulkheadFull when too many calls are in flight.
 func (c *Client) GetByTitle(ctx context.Context, title string) (*Response, error) {
+	select {
+	case c.slots <- struct{}{}:
+		defer func() { <-c.slots }()
+	default:
+		c.logger.Warn("box office bulkhead full", "title", title)
+		return nil, ErrBulkheadFull
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	data, err := c.fetch(ctx, title)
+	switch {
+	case err == nil:
+		c.breaker.success()
+	case ctx.Err() != nil:
+		// The caller gave up; that says nothing about upstream health.
+		c.breaker.cancel()
+	default:
+		c.breaker.failure()
+	}
+	return data, err
+}
+
+func (c *Client) fetch(ctx context.Context, title string) (*Response, error) {
 	u, err := url.Parse(c.baseURL + "/boxoffice")
 	if err != nil {
 		return nil, fmt.Errorf("invalid base URL: %w", err)
@@ -98,5 +177,9 @@ func (c *Client) GetByTitle(ctx context.Context, title string) (*Response, error
 		return nil, fmt.Errorf("decode failed: %w", err)
 	}
 
+	data.Source = c.Name()
+	if data.LastReported.IsZero() {
+		data.LastReported = time.Now().UTC()
+	}
 	return &data, nil
 }
@@ -0,0 +1,174 @@
+package boxoffice
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvColumns are the recognised CSV header names; only title is required.
+var csvColumns = []string{
+	"title", "distributor", "releaseDate", "budget",
+	"worldwide", "domestic", "international", "openingWeekendUSA",
+	"theaterCount", "weeksInRelease", "currency", "mpaRating", "lastReported",
+}
+
+// CSVProvider serves box office data from a local CSV file loaded at startup.
+// The first row is a header naming the columns in csvColumns, in any order.
+// lastReported is RFC 3339 or YYYY-MM-DD; rows without it use the file's
+// modification time. currency is an ISO 4217 code and defaults to USD.
+type CSVProvider struct {
+	records map[string]Response
+}
+
+// LoadCSVProvider reads and validates a CSV file.
+func LoadCSVProvider(path string) (*CSVProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open box office csv: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat box office csv: %w", err)
+	}
+
+	records, err := parseCSV(f, info.ModTime().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("parse box office csv %s: %w", path, err)
+	}
+	return &CSVProvider{records: records}, nil
+}
+
+func parseCSV(r io.Reader, defaultReported time.Time) (map[string]Response, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	if _, ok := index["title"]; !ok {
+		return nil, errors.New("missing title column")
+	}
+	for name := range index {
+		if !isCSVColumn(name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+
+	records := make(map[string]Response)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		get := func(col string) string {
+			if i, ok := index[col]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		getInt := func(col string) (int64, error) {
+			v := get(col)
+			if v == "" {
+				return 0, nil
+			}
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("line %d: invalid %s %q", line, col, v)
+			}
+			return n, nil
+		}
+
+		rec := Response{
+			Title:        get("title"),
+			Distributor:  get("distributor"),
+			ReleaseDate:  get("releaseDate"),
+			Currency:     strings.ToUpper(get("currency")),
+			MPARating:    get("mpaRating"),
+			LastReported: defaultReported,
+		}
+		if rec.Title == "" {
+			return nil, fmt.Errorf("line %d: empty title", line)
+		}
+		if rec.Budget, err = getInt("budget"); err != nil {
+			return nil, err
+		}
+		if rec.Revenue.Worldwide, err = getInt("worldwide"); err != nil {
+			return nil, err
+		}
+		if rec.Revenue.OpeningWeekendUSA, err = getInt("openingWeekendUSA"); err != nil {
+			return nil, err
+		}
+		if rec.Revenue.Domestic, err = getInt("domestic"); err != nil {
+			return nil, err
+		}
+		if rec.Revenue.International, err = getInt("international"); err != nil {
+			return nil, err
+		}
+		theaters, err := getInt("theaterCount")
+		if err != nil {
+			return nil, err
+		}
+		weeks, err := getInt("weeksInRelease")
+		if err != nil {
+			return nil, err
+		}
+		rec.TheaterCount, rec.WeeksInRelease = int(theaters), int(weeks)
+		if v := get("lastReported"); v != "" {
+			if rec.LastReported, err = parseReported(v); err != nil {
+				return nil, fmt.Errorf("line %d: invalid lastReported %q", line, v)
+			}
+		}
+
+		records[strings.ToLower(rec.Title)] = rec
+	}
+	return records, nil
+}
+
+func isCSVColumn(name string) bool {
+	for _, col := range csvColumns {
+		if col == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parseReported(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// Name identifies the CSV file as a data source.
+func (p *CSVProvider) Name() string {
+	return "LocalCSV"
+}
+
+// GetByTitle looks the title up case-insensitively.
+func (p *CSVProvider) GetByTitle(_ context.Context, title string) (*Response, error) {
+	rec, ok := p.records[strings.ToLower(title)]
+	if !ok {
+		return nil, nil
+	}
+	rec.Source = p.Name()
+	return &rec, nil
+}
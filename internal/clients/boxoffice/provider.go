@@ -0,0 +1,148 @@
+package boxoffice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Provider supplies box office data for a movie title. GetByTitle returns nil, nil
+// when the provider has no data for the title. Returned responses carry the
+// provider's name in Source.
+type Provider interface {
+	Name() string
+	GetByTitle(ctx context.Context, title string) (*Response, error)
+}
+
+// MergeStrategy decides which provider's answer wins when several know a title.
+type MergeStrategy string
+
+// Supported merge strategies.
+const (
+	// MergePriority asks providers in configured order and keeps the first hit.
+	MergePriority MergeStrategy = "priority"
+	// MergeFirstWins asks all providers at once and keeps the first hit to arrive.
+	MergeFirstWins MergeStrategy = "first-wins"
+	// MergeFreshest asks all providers and keeps the hit with the latest LastReported.
+	MergeFreshest MergeStrategy = "freshest"
+)
+
+// ParseMergeStrategy validates a merge strategy name.
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	switch MergeStrategy(s) {
+	case MergePriority, MergeFirstWins, MergeFreshest:
+		return MergeStrategy(s), nil
+	default:
+		return "", fmt.Errorf("unknown box office merge strategy %q", s)
+	}
+}
+
+// MultiProvider combines several providers under a merge strategy.
+type MultiProvider struct {
+	providers []Provider
+	strategy  MergeStrategy
+}
+
+// NewMultiProvider creates a MultiProvider; providers are given in priority order.
+func NewMultiProvider(strategy MergeStrategy, providers ...Provider) *MultiProvider {
+	return &MultiProvider{providers: providers, strategy: strategy}
+}
+
+// Name lists the combined providers.
+func (m *MultiProvider) Name() string {
+	names := make([]string, len(m.providers))
+	for i, p := range m.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// GetByTitle queries the providers according to the merge strategy. Errors are
+// only returned when no provider had data and at least one of them failed, so a
+// healthy provider can mask an unhealthy one.
+func (m *MultiProvider) GetByTitle(ctx context.Context, title string) (*Response, error) {
+	switch m.strategy {
+	case MergeFirstWins:
+		return m.firstWins(ctx, title)
+	case MergeFreshest:
+		return m.freshest(ctx, title)
+	default:
+		return m.priority(ctx, title)
+	}
+}
+
+func (m *MultiProvider) priority(ctx context.Context, title string) (*Response, error) {
+	var errs []error
+	for _, p := range m.providers {
+		resp, err := p.GetByTitle(ctx, title)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		if resp != nil {
+			return resp, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+type providerResult struct {
+	name string
+	resp *Response
+	err  error
+}
+
+// fanOut queries every provider concurrently. Results arrive in completion order;
+// cancel stops the outstanding calls.
+func (m *MultiProvider) fanOut(ctx context.Context, title string) (<-chan providerResult, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan providerResult, len(m.providers))
+	for _, p := range m.providers {
+		go func(p Provider) {
+			resp, err := p.GetByTitle(ctx, title)
+			results <- providerResult{name: p.Name(), resp: resp, err: err}
+		}(p)
+	}
+	return results, cancel
+}
+
+func (m *MultiProvider) firstWins(ctx context.Context, title string) (*Response, error) {
+	results, cancel := m.fanOut(ctx, title)
+	defer cancel()
+
+	var errs []error
+	for range m.providers {
+		res := <-results
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.name, res.err))
+			continue
+		}
+		if res.resp != nil {
+			return res.resp, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (m *MultiProvider) freshest(ctx context.Context, title string) (*Response, error) {
+	results, cancel := m.fanOut(ctx, title)
+	defer cancel()
+
+	var best *Response
+	var errs []error
+	for range m.providers {
+		res := <-results
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.name, res.err))
+			continue
+		}
+		if res.resp != nil && (best == nil || res.resp.LastReported.After(best.LastReported)) {
+			best = res.resp
+		}
+	}
+	if best != nil {
+		return best, nil
+	}
+	return nil, errors.Join(errs...)
+}
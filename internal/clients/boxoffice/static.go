@@ -0,0 +1,64 @@
+package boxoffice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StaticProvider serves box office data from an in-memory fixture, loaded from
+// a JSON object keyed by title (the mock-boxoffice.json format).
+type StaticProvider struct {
+	records map[string]Response
+}
+
+// NewStaticProvider creates a provider over the given records, keyed by title.
+func NewStaticProvider(records map[string]Response) *StaticProvider {
+	p := &StaticProvider{records: make(map[string]Response, len(records))}
+	for title, rec := range records {
+		p.records[strings.ToLower(title)] = rec
+	}
+	return p
+}
+
+// LoadStaticProvider reads a JSON fixture file. Records without lastReported are
+// stamped with the file's modification time.
+func LoadStaticProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read box office fixture: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat box office fixture: %w", err)
+	}
+
+	var records map[string]Response
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("decode box office fixture: %w", err)
+	}
+	for title, rec := range records {
+		if rec.LastReported.IsZero() {
+			rec.LastReported = info.ModTime().UTC()
+			records[title] = rec
+		}
+	}
+	return NewStaticProvider(records), nil
+}
+
+// Name identifies the fixture as a data source.
+func (p *StaticProvider) Name() string {
+	return "StaticFixture"
+}
+
+// GetByTitle looks the title up case-insensitively.
+func (p *StaticProvider) GetByTitle(_ context.Context, title string) (*Response, error) {
+	rec, ok := p.records[strings.ToLower(title)]
+	if !ok {
+		return nil, nil
+	}
+	rec.Source = p.Name()
+	return &rec, nil
+}
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Config captures all runtime settings sourced from environment variables only.
@@ -13,6 +15,38 @@ type Config struct {
 	DatabaseURL  string
 	BoxOfficeURL string
 	BoxOfficeKey string
+
+	// Optional settings with defaults.
+	EnrichmentWorkers     int
+	EnrichmentMaxAttempts int
+
+	BoxOfficeRefreshInterval time.Duration // zero disables the periodic refresh
+	BoxOfficeRefreshMaxAge   time.Duration
+	BoxOfficeRefreshBatch    int
+	BoxOfficeRefreshRPS      int
+
+	BoxOfficeBreakerThreshold int
+	BoxOfficeBreakerCooldown  time.Duration
+	BoxOfficeMaxConcurrency   int
+
+	// BoxOfficeCache selects the lookup cache backend: memory, mysql or none.
+	BoxOfficeCache       string
+	BoxOfficeCacheSize   int           // entries kept by the memory backend
+	BoxOfficeCacheTTL    time.Duration // zero disables caching of hits
+	BoxOfficeNotFoundTTL time.Duration // zero disables caching of misses, even with backend none
+
+	// BoxOfficeProviders lists providers in priority order: apifox, csv, static.
+	BoxOfficeProviders     []string
+	BoxOfficeMergeStrategy string
+	BoxOfficeCSVPath       string
+	BoxOfficeFixturePath   string
+
+	FacetCacheTTL time.Duration // zero disables caching of facet counts
+
+	// CursorSecret signs pagination cursors; when empty a random key is used,
+	// so cursors do not survive restarts or move between replicas.
+	CursorSecret      string
+	TotalCountTimeout time.Duration // zero lets includeTotal counts run to completion
 }
 
 // Load reads required settings from the process environment and enforces presence.
@@ -30,9 +64,130 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("missing required env vars: %s", strings.Join(missing, ", "))
 	}
 
+	var err error
+	if cfg.EnrichmentWorkers, err = envInt("ENRICHMENT_WORKERS", 2); err != nil {
+		return Config{}, err
+	}
+	if cfg.EnrichmentMaxAttempts, err = envInt("ENRICHMENT_MAX_ATTEMPTS", 5); err != nil {
+		return Config{}, err
+	}
+	if cfg.BoxOfficeRefreshInterval, err = envDuration("BOXOFFICE_REFRESH_INTERVAL", time.Hour); err != nil {
+		return Config{}, err
+	}
+	if cfg.BoxOfficeRefreshMaxAge, err = envDuration("BOXOFFICE_REFRESH_MAX_AGE", 24*time.Hour); err != nil {
+		return Config{}, err
+	}
+	if cfg.BoxOfficeRefreshBatch, err = envInt("BOXOFFICE_REFRESH_BATCH", 100); err != nil {
+		return Config{}, err
+	}
+	if cfg.BoxOfficeRefreshRPS, err = envInt("BOXOFFICE_REFRESH_RPS", 2); err != nil {
+		return Config{}, err
+	}
+	if cfg.BoxOfficeBreakerThreshold, err = envInt("BOXOFFICE_BREAKER_THRESHOLD", 5); err != nil {
+		return Config{}, err
+	}
+	if cfg.BoxOfficeBreakerCooldown, err = envDuration("BOXOFFICE_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return Config{}, err
+	}
+	if cfg.BoxOfficeMaxConcurrency, err = envInt("BOXOFFICE_MAX_CONCURRENCY", 8); err != nil {
+		return Config{}, err
+	}
+	cfg.BoxOfficeCache = envString("BOXOFFICE_CACHE", "memory")
+	switch cfg.BoxOfficeCache {
+	case "memory", "mysql", "none":
+	default:
+		return Config{}, fmt.Errorf("invalid BOXOFFICE_CACHE: %q", cfg.BoxOfficeCache)
+	}
+	if cfg.BoxOfficeCacheSize, err = envInt("BOXOFFICE_CACHE_SIZE", 1000); err != nil {
+		return Config{}, err
+	}
+	if cfg.BoxOfficeCacheTTL, err = envDuration("BOXOFFICE_CACHE_TTL", time.Hour); err != nil {
+		return Config{}, err
+	}
+	if cfg.BoxOfficeNotFoundTTL, err = envDuration("BOXOFFICE_NOT_FOUND_TTL", 10*time.Minute); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.FacetCacheTTL, err = envDuration("FACET_CACHE_TTL", 30*time.Second); err != nil {
+		return Config{}, err
+	}
+
+	cfg.CursorSecret = strings.TrimSpace(os.Getenv("CURSOR_SECRET"))
+	if cfg.TotalCountTimeout, err = envDuration("TOTAL_COUNT_TIMEOUT", 500*time.Millisecond); err != nil {
+		return Config{}, err
+	}
+
+	cfg.BoxOfficeProviders = envList("BOXOFFICE_PROVIDERS", []string{"apifox"})
+	cfg.BoxOfficeMergeStrategy = envString("BOXOFFICE_MERGE_STRATEGY", "priority")
+	cfg.BoxOfficeCSVPath = strings.TrimSpace(os.Getenv("BOXOFFICE_CSV_PATH"))
+	cfg.BoxOfficeFixturePath = strings.TrimSpace(os.Getenv("BOXOFFICE_FIXTURE_PATH"))
+	for _, name := range cfg.BoxOfficeProviders {
+		switch name {
+		case "apifox":
+		case "csv":
+			if cfg.BoxOfficeCSVPath == "" {
+				return Config{}, fmt.Errorf("BOXOFFICE_CSV_PATH is required for the csv box office provider")
+			}
+		case "static":
+			if cfg.BoxOfficeFixturePath == "" {
+				return Config{}, fmt.Errorf("BOXOFFICE_FIXTURE_PATH is required for the static box office provider")
+			}
+		default:
+			return Config{}, fmt.Errorf("invalid BOXOFFICE_PROVIDERS: unknown provider %q", name)
+		}
+	}
+
 	return cfg, nil
 }
 
+// envString reads a string env var, falling back to def when unset.
+func envString(name, def string) string {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		return v
+	}
+	return def
+}
+
+// envList reads a comma-separated env var, falling back to def when unset.
+func envList(name string, def []string) []string {
+	var out []string
+	for _, part := range strings.Split(os.Getenv(name), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	if len(out) == 0 {
+		return def
+	}
+	return out
+}
+
+// envInt reads a positive integer env var, falling back to def when unset.
+func envInt(name string, def int) (int, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid %s: must be a positive integer", name)
+	}
+	return v, nil
+}
+
+// envDuration reads a non-negative Go duration (e.g. 30m) env var, falling back to def when unset.
+func envDuration(name string, def time.Duration) (time.Duration, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return def, nil
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid %s: must be a non-negative duration", name)
+	}
+	return v, nil
+}
+
 // HTTPAddr returns a TCP address usable by net/http (e.g. 0.0.0.0:8080).
 func (c Config) HTTPAddr() string {
 	if strings.HasPrefix(c.Port, ":") {
@@ -0,0 +1,267 @@
+package enrichment
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/robin-camp/movies/internal/clients/boxoffice"
+	"github.com/robin-camp/movies/internal/store"
+)
+
+// Enricher merges box office data from a provider into movies.
+type Enricher struct {
+	movieStore *store.MovieStore
+	provider   boxoffice.Provider
+	logger     *slog.Logger
+}
+
+// NewEnricher creates an Enricher.
+func NewEnricher(ms *store.MovieStore, provider boxoffice.Provider, logger *slog.Logger) *Enricher {
+	return &Enricher{movieStore: ms, provider: provider, logger: logger}
+}
+
+// Prepare fetches box office data for a movie that is not stored yet and merges
+// it in memory; the returned row is nil when the upstream does not know the
+// title. The caller persists both, e.g. through MovieStore.CreateEnriched.
+func (e *Enricher) Prepare(ctx context.Context, movie *store.Movie) (*store.BoxOfficeRow, error) {
+	boResp, err := e.provider.GetByTitle(ctx, movie.Title)
+	if err != nil || boResp == nil {
+		return nil, err
+	}
+	return merge(movie, boResp), nil
+}
+
+// Enrich fetches box office data for a stored movie and persists the merged
+// distributor, budget, mpaRating and box office row in one transaction. On
+// success movie reflects the stored state. A title unknown to the upstream is
+// not an error; found is false and the movie is left untouched.
+func (e *Enricher) Enrich(ctx context.Context, movie *store.Movie) (found bool, err error) {
+	boResp, err := e.provider.GetByTitle(ctx, movie.Title)
+	if err != nil {
+		return false, err
+	}
+	if boResp == nil {
+		return false, nil
+	}
+
+	stored, err := e.movieStore.ApplyEnrichment(ctx, movie.ID, func(current *store.Movie) *store.BoxOfficeRow {
+		return merge(current, boResp)
+	})
+	if err != nil {
+		return false, err
+	}
+	*movie = *stored
+	return true, nil
+}
+
+// merge folds a provider response into movie and returns the box office row to
+// store. User-provided values take precedence; values that came from a provider
+// earlier may be replaced by fresher ones. The response's Source is recorded as
+// the origin of every value it supplies.
+func merge(movie *store.Movie, boResp *boxoffice.Response) *store.BoxOfficeRow {
+	source := boResp.Source
+	if movie.FieldSources == nil {
+		movie.FieldSources = store.FieldSources{}
+	}
+	if boResp.Distributor != "" && upstreamOwned(movie, store.FieldDistributor, movie.Distributor == nil) {
+		movie.Distributor = &boResp.Distributor
+		movie.FieldSources[store.FieldDistributor] = source
+	}
+	if boResp.Budget > 0 && upstreamOwned(movie, store.FieldBudget, movie.Budget == nil) {
+		movie.Budget = &boResp.Budget
+		movie.FieldSources[store.FieldBudget] = source
+	}
+	if boResp.MPARating != "" && upstreamOwned(movie, store.FieldMPARating, movie.MPARating == nil) {
+		movie.MPARating = &boResp.MPARating
+		movie.FieldSources[store.FieldMPARating] = source
+	}
+
+	lastReported := boResp.LastReported
+	if lastReported.IsZero() {
+		lastReported = time.Now()
+	}
+	currency := boResp.Currency
+	if currency == "" {
+		currency = store.BaseCurrency
+	}
+	boRow := &store.BoxOfficeRow{
+		MovieID:      movie.ID,
+		Gross:        boResp.Revenue.Worldwide,
+		Currency:     currency,
+		Source:       source,
+		LastReported: lastReported.UTC(),
+	}
+	if boResp.Revenue.Domestic > 0 {
+		boRow.Domestic = &boResp.Revenue.Domestic
+	}
+	if boResp.Revenue.International > 0 {
+		boRow.International = &boResp.Revenue.International
+	}
+	if boResp.Revenue.OpeningWeekendUSA > 0 {
+		boRow.OpeningWeekendUSA = &boResp.Revenue.OpeningWeekendUSA
+	}
+	if boResp.TheaterCount > 0 {
+		boRow.TheaterCount = &boResp.TheaterCount
+	}
+	if boResp.WeeksInRelease > 0 {
+		boRow.WeeksInRelease = &boResp.WeeksInRelease
+	}
+	return boRow
+}
+
+// upstreamOwned reports whether enrichment may write the field: it is empty, or
+// its current value was itself supplied by an upstream source.
+func upstreamOwned(movie *store.Movie, field string, empty bool) bool {
+	return empty || !movie.FieldSources.IsUser(field)
+}
+
+// Refresh outcomes.
+const (
+	RefreshUpdated   = "updated"
+	RefreshUnchanged = "unchanged"
+	RefreshNotFound  = "not_found"
+	RefreshFailed    = "failed"
+)
+
+// FieldChange describes one value that a refresh changed.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// RefreshResult reports what a forced re-fetch did to one movie.
+type RefreshResult struct {
+	MovieID   string           `json:"movieId"`
+	Title     string           `json:"title"`
+	Status    string           `json:"status"`
+	Changes   []FieldChange    `json:"changes,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	BoxOffice *store.BoxOffice `json:"boxOffice,omitempty"`
+}
+
+// Refresh re-fetches box office data for the movie using the same precedence
+// rules as Enrich and reports which stored values changed. It bypasses the
+// lookup cache and replaces the cached answer. Upstream failures
+// are reported in the result rather than returned; the error is reserved for
+// database failures.
+func (e *Enricher) Refresh(ctx context.Context, movie *store.Movie) (*RefreshResult, error) {
+	result := &RefreshResult{MovieID: movie.ID, Title: movie.Title}
+	previous := *movie
+
+	before, err := e.movieStore.GetBoxOffice(ctx, movie.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := e.Enrich(boxoffice.BypassCache(ctx), movie)
+	if err != nil {
+		result.Status = RefreshFailed
+		result.Error = err.Error()
+		if before != nil {
+			result.BoxOffice = before.ToBoxOffice()
+		}
+		return result, nil
+	}
+	if !found {
+		result.Status = RefreshNotFound
+		if before != nil {
+			result.BoxOffice = before.ToBoxOffice()
+		}
+		return result, nil
+	}
+
+	after, err := e.movieStore.GetBoxOffice(ctx, movie.ID)
+	if err != nil {
+		return nil, err
+	}
+	if after != nil {
+		result.BoxOffice = after.ToBoxOffice()
+	}
+
+	result.Changes = append(diffMovie(&previous, movie), diffBoxOffice(before, after)...)
+	result.Status = RefreshUnchanged
+	if len(result.Changes) > 0 {
+		result.Status = RefreshUpdated
+	}
+	return result, nil
+}
+
+// diffMovie lists the enrichable movie fields that differ between two versions.
+func diffMovie(before, after *store.Movie) []FieldChange {
+	var changes []FieldChange
+	if !equalString(before.Distributor, after.Distributor) {
+		changes = append(changes, FieldChange{Field: store.FieldDistributor, From: before.Distributor, To: after.Distributor})
+	}
+	if !equalInt64(before.Budget, after.Budget) {
+		changes = append(changes, FieldChange{Field: store.FieldBudget, From: before.Budget, To: after.Budget})
+	}
+	if !equalString(before.MPARating, after.MPARating) {
+		changes = append(changes, FieldChange{Field: store.FieldMPARating, From: before.MPARating, To: after.MPARating})
+	}
+	return changes
+}
+
+// diffBoxOffice lists the box office values that differ between two rows.
+// fetched_at and last_reported are bookkeeping and always move, so they are skipped.
+func diffBoxOffice(before, after *store.BoxOfficeRow) []FieldChange {
+	if after == nil {
+		return nil
+	}
+	if before == nil {
+		before = &store.BoxOfficeRow{}
+	}
+
+	var changes []FieldChange
+	add := func(field string, from, to interface{}) {
+		changes = append(changes, FieldChange{Field: field, From: from, To: to})
+	}
+
+	if before.Gross != after.Gross {
+		add("boxOffice.revenue.worldwide", before.Gross, after.Gross)
+	}
+	if !equalInt64(before.Domestic, after.Domestic) {
+		add("boxOffice.revenue.domestic", before.Domestic, after.Domestic)
+	}
+	if !equalInt64(before.International, after.International) {
+		add("boxOffice.revenue.international", before.International, after.International)
+	}
+	if !equalInt64(before.OpeningWeekendUSA, after.OpeningWeekendUSA) {
+		add("boxOffice.revenue.openingWeekendUSA", before.OpeningWeekendUSA, after.OpeningWeekendUSA)
+	}
+	if !equalInt(before.TheaterCount, after.TheaterCount) {
+		add("boxOffice.theaterCount", before.TheaterCount, after.TheaterCount)
+	}
+	if !equalInt(before.WeeksInRelease, after.WeeksInRelease) {
+		add("boxOffice.weeksInRelease", before.WeeksInRelease, after.WeeksInRelease)
+	}
+	if before.Currency != after.Currency {
+		add("boxOffice.currency", before.Currency, after.Currency)
+	}
+	if before.Source != after.Source {
+		add("boxOffice.source", before.Source, after.Source)
+	}
+	return changes
+}
+
+func equalString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func equalInt64(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func equalInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,153 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/robin-camp/movies/internal/clients/boxoffice"
+	"github.com/robin-camp/movies/internal/store"
+)
+
+const (
+	pollInterval = time.Second
+	jobLease     = 30 * time.Second
+	jobTimeout   = 10 * time.Second
+	backoffBase  = 2 * time.Second
+	backoffMax   = 5 * time.Minute
+)
+
+// Pool runs queued enrichment jobs on a fixed number of workers. Jobs the box
+// office client rejects without calling the upstream (breaker open, bulkhead
+// full) are put back for after the breaker cooldown without using an attempt,
+// so an outage longer than the retry window does not dead-letter the queue.
+type Pool struct {
+	jobs        *store.JobStore
+	movieStore  *store.MovieStore
+	enricher    *Enricher
+	logger      *slog.Logger
+	workers     int
+	maxAttempts int
+	cooldown    time.Duration
+	wg          sync.WaitGroup
+}
+
+// NewPool creates a worker pool; call Start to begin processing. cooldown is
+// the box office breaker cooldown.
+func NewPool(js *store.JobStore, ms *store.MovieStore, enricher *Enricher, workers, maxAttempts int, cooldown time.Duration, logger *slog.Logger) *Pool {
+	return &Pool{
+		jobs:        js,
+		movieStore:  ms,
+		enricher:    enricher,
+		logger:      logger,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		cooldown:    cooldown,
+	}
+}
+
+// Start launches the workers; they stop when ctx is cancelled.
+func (p *Pool) Start(ctx context.Context) {
+	p.logger.Info("enrichment workers starting", "workers", p.workers)
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.run(ctx)
+		}()
+	}
+}
+
+// Wait blocks until every worker has returned.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Pool) run(ctx context.Context) {
+	for {
+		job, err := p.jobs.Claim(ctx, jobLease)
+		if err != nil && ctx.Err() == nil {
+			p.logger.Error("failed to claim enrichment job", "err", err)
+		}
+		if job != nil {
+			p.process(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (p *Pool) process(ctx context.Context, job *store.EnrichmentJob) {
+	jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	err := p.enrich(jobCtx, job.MovieID)
+	if err == nil {
+		if err := p.jobs.Complete(ctx, job.ID); err != nil {
+			p.logger.Error("failed to complete enrichment job", "job", job.ID, "err", err)
+		}
+		return
+	}
+
+	if errors.Is(err, boxoffice.ErrCircuitOpen) || errors.Is(err, boxoffice.ErrBulkheadFull) {
+		delay := rejectedDelay(p.cooldown)
+		p.logger.Info("enrichment job deferred, upstream unavailable", "job", job.ID, "movie", job.MovieID, "retryIn", delay, "err", err)
+		if err := p.jobs.Defer(ctx, job.ID, delay, err.Error()); err != nil {
+			p.logger.Error("failed to defer enrichment job", "job", job.ID, "err", err)
+		}
+		return
+	}
+
+	if job.Attempts >= p.maxAttempts {
+		p.logger.Warn("enrichment job dead-lettered", "job", job.ID, "movie", job.MovieID, "attempts", job.Attempts, "err", err)
+		if err := p.jobs.Bury(ctx, job.ID, err.Error()); err != nil {
+			p.logger.Error("failed to bury enrichment job", "job", job.ID, "err", err)
+		}
+		return
+	}
+
+	delay := backoff(job.Attempts)
+	p.logger.Warn("enrichment job failed, retrying", "job", job.ID, "movie", job.MovieID, "attempts", job.Attempts, "retryIn", delay, "err", err)
+	if err := p.jobs.Retry(ctx, job.ID, delay, err.Error()); err != nil {
+		p.logger.Error("failed to reschedule enrichment job", "job", job.ID, "err", err)
+	}
+}
+
+func (p *Pool) enrich(ctx context.Context, movieID string) error {
+	movie, err := p.movieStore.GetByID(ctx, movieID)
+	if err != nil {
+		return err
+	}
+	if movie == nil {
+		// Deleted in the meantime; nothing left to enrich.
+		return nil
+	}
+	_, err = p.enricher.Enrich(ctx, movie)
+	return err
+}
+
+// rejectedDelay returns the cooldown plus up to 50% jitter, so deferred jobs
+// reach the half-open breaker spread out rather than all at once.
+func rejectedDelay(cooldown time.Duration) time.Duration {
+	if cooldown <= 0 {
+		cooldown = backoffBase
+	}
+	return cooldown + time.Duration(rand.Int63n(int64(cooldown/2)+1))
+}
+
+// backoff returns an exponential delay with up to 50% jitter for the given attempt.
+func backoff(attempt int) time.Duration {
+	delay := backoffBase << (attempt - 1)
+	if delay <= 0 || delay > backoffMax {
+		delay = backoffMax
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
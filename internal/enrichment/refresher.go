@@ -0,0 +1,143 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/robin-camp/movies/internal/clients/boxoffice"
+	"github.com/robin-camp/movies/internal/store"
+)
+
+// refreshLockName is the MySQL named lock that elects the refreshing replica.
+const refreshLockName = "movies.boxoffice_refresh"
+
+// RefresherConfig controls the periodic box office refresh.
+type RefresherConfig struct {
+	Interval  time.Duration // time between cycles; zero disables the refresher
+	MaxAge    time.Duration // rows fetched longer ago than this are refreshed
+	BatchSize int           // maximum movies refreshed per cycle
+	RPS       int           // upstream calls per second
+}
+
+// Refresher periodically re-fetches stale movie_box_office rows. Only the
+// replica holding the MySQL leader lock does the work in a given cycle.
+type Refresher struct {
+	db         *store.DB
+	movieStore *store.MovieStore
+	enricher   *Enricher
+	cfg        RefresherConfig
+	logger     *slog.Logger
+	wg         sync.WaitGroup
+}
+
+// NewRefresher creates a Refresher; call Start to schedule it.
+func NewRefresher(db *store.DB, ms *store.MovieStore, enricher *Enricher, cfg RefresherConfig, logger *slog.Logger) *Refresher {
+	return &Refresher{db: db, movieStore: ms, enricher: enricher, cfg: cfg, logger: logger}
+}
+
+// Start schedules refresh cycles until ctx is cancelled.
+func (r *Refresher) Start(ctx context.Context) {
+	if r.cfg.Interval <= 0 {
+		r.logger.Info("box office refresher disabled")
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.cfg.Interval + jitter(r.cfg.Interval/10)):
+			}
+			r.RunOnce(ctx)
+		}
+	}()
+}
+
+// Wait blocks until the scheduling goroutine has returned.
+func (r *Refresher) Wait() {
+	r.wg.Wait()
+}
+
+// RunOnce performs a single refresh cycle if this replica wins the leader lock.
+func (r *Refresher) RunOnce(ctx context.Context) {
+	release, ok, err := r.db.TryLock(ctx, refreshLockName)
+	if err != nil {
+		r.logger.Error("box office refresh lock failed", "err", err)
+		return
+	}
+	if !ok {
+		r.logger.Debug("box office refresh skipped, another replica holds the lock")
+		return
+	}
+	defer release()
+
+	movies, err := r.movieStore.ListStaleBoxOffice(ctx, r.cfg.MaxAge, r.cfg.BatchSize)
+	if err != nil {
+		r.logger.Error("failed to list stale box office rows", "err", err)
+		return
+	}
+	if len(movies) == 0 {
+		return
+	}
+
+	limiter := time.NewTicker(time.Second / time.Duration(r.cfg.RPS))
+	defer limiter.Stop()
+
+	refreshed, failed := 0, 0
+	for i := range movies {
+		select {
+		case <-ctx.Done():
+			return
+		case <-limiter.C:
+		}
+
+		found, err := r.refresh(ctx, &movies[i])
+		if errors.Is(err, boxoffice.ErrCircuitOpen) || errors.Is(err, boxoffice.ErrBulkheadFull) {
+			// The upstream is unavailable, not the title; try the rest next cycle.
+			r.logger.Warn("box office refresh stopped, upstream unavailable", "err", err)
+			break
+		}
+		if err != nil {
+			failed++
+			r.logger.Warn("box office refresh failed", "title", movies[i].Title, "err", err)
+			if err := r.movieStore.RecordRefreshFailure(ctx, movies[i].ID, r.cfg.Interval, r.cfg.MaxAge); err != nil {
+				r.logger.Warn("failed to record box office refresh failure", "title", movies[i].Title, "err", err)
+			}
+			continue
+		}
+		if !found {
+			// Keep the last known figures but push the row to the back of the queue.
+			if err := r.movieStore.TouchBoxOffice(ctx, movies[i].ID); err != nil {
+				r.logger.Warn("failed to touch box office row", "title", movies[i].Title, "err", err)
+			}
+			continue
+		}
+		refreshed++
+	}
+
+	r.logger.Info("box office refresh finished", "candidates", len(movies), "refreshed", refreshed, "failed", failed)
+}
+
+// refresh re-fetches one movie's box office data. The lookup cache is
+// bypassed: an answer cached for longer than MaxAge would otherwise be stored
+// again as if it were fresh.
+func (r *Refresher) refresh(ctx context.Context, movie *store.Movie) (bool, error) {
+	ctx, cancel := context.WithTimeout(boxoffice.BypassCache(ctx), jobTimeout)
+	defer cancel()
+	return r.enricher.Enrich(ctx, movie)
+}
+
+// jitter returns a random duration in [0, max).
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -0,0 +1,57 @@
+package enrichment
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/robin-camp/movies/internal/clients/boxoffice"
+	"github.com/robin-camp/movies/internal/store"
+)
+
+// countingProvider reports every title as unknown and counts the lookups.
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Name() string { return "test" }
+
+func (p *countingProvider) GetByTitle(ctx context.Context, title string) (*boxoffice.Response, error) {
+	p.calls++
+	return nil, nil
+}
+
+func TestRefresherBypassesLookupCache(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	upstream := &countingProvider{}
+	cache := boxoffice.NewMemoryCache(10)
+	cached := boxoffice.NewCachedProvider(upstream, cache,
+		boxoffice.CacheOptions{TTL: time.Hour, NotFoundTTL: time.Hour}, logger)
+
+	// A cache hit would be applied to the movie, which needs the database;
+	// the refresher must ask the upstream instead, which knows nothing.
+	stale := &boxoffice.Response{Title: "Alien", Revenue: boxoffice.Revenue{Worldwide: 1}}
+	if err := cache.Set(context.Background(), "test:alien", stale, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRefresher(nil, nil, NewEnricher(nil, cached, logger), RefresherConfig{MaxAge: time.Minute}, logger)
+	found, err := r.refresh(context.Background(), &store.Movie{ID: "01HZX", Title: "Alien"})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if found {
+		t.Error("refresh used the cached answer")
+	}
+	if upstream.calls != 1 {
+		t.Errorf("upstream called %d times, want 1", upstream.calls)
+	}
+
+	// The fresh answer replaces the cached one.
+	resp, ok, err := cache.Get(context.Background(), "test:alien")
+	if err != nil || !ok || resp != nil {
+		t.Errorf("cache entry = %+v, %v, %v, want the fresh not-found answer", resp, ok, err)
+	}
+}
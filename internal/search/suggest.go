@@ -0,0 +1,271 @@
+package search
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Suggestion is a title offered for a typed prefix.
+type Suggestion struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	ReleaseYear int    `json:"releaseYear"`
+}
+
+// TitleIndex is an in-memory prefix index of movie titles for as-you-type
+// suggestions. Titles are folded and indexed from the start of every word, so
+// "knight" finds "The Dark Knight"; matches at the start of the title rank
+// first. It is safe for concurrent use. Each process keeps its own index, so it
+// only sees the writes that go through it after the initial Load.
+type TitleIndex struct {
+	mu      sync.RWMutex
+	root    *trieNode
+	entries map[string]Suggestion
+}
+
+// trieNode is one rune of an indexed key. ids holds the movies whose key ends
+// here. minStart and minAny are the shortest title lengths indexed at or below
+// the node from the start of a title and from any word, math.MaxInt when there
+// are none; Suggest visits the most promising branches first and stops once
+// they cannot beat what it has.
+type trieNode struct {
+	children map[rune]*trieNode
+	ids      map[string]posting
+	minStart int
+	minAny   int
+}
+
+// posting is a movie whose key ends at a node: the position of the word the key
+// starts at and the length of the title.
+type posting struct {
+	pos    int
+	length int
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: make(map[rune]*trieNode), minStart: math.MaxInt, minAny: math.MaxInt}
+}
+
+// include lowers the node's bounds to admit p.
+func (n *trieNode) include(p posting) {
+	if p.pos == 0 {
+		n.minStart = min(n.minStart, p.length)
+	}
+	n.minAny = min(n.minAny, p.length)
+}
+
+// updateBounds recomputes the bounds from the node's own postings and its
+// children's bounds.
+func (n *trieNode) updateBounds() {
+	n.minStart, n.minAny = math.MaxInt, math.MaxInt
+	for _, p := range n.ids {
+		n.include(p)
+	}
+	for _, child := range n.children {
+		n.minStart = min(n.minStart, child.minStart)
+		n.minAny = min(n.minAny, child.minAny)
+	}
+}
+
+// NewTitleIndex creates an empty TitleIndex.
+func NewTitleIndex() *TitleIndex {
+	return &TitleIndex{root: newTrieNode(), entries: make(map[string]Suggestion)}
+}
+
+// Load replaces the contents of the index.
+func (x *TitleIndex) Load(entries []Suggestion) {
+	root, byID := newTrieNode(), make(map[string]Suggestion, len(entries))
+	for _, e := range entries {
+		byID[e.ID] = e
+		insertKeys(root, e)
+	}
+
+	x.mu.Lock()
+	x.root, x.entries = root, byID
+	x.mu.Unlock()
+}
+
+// Put adds a movie or replaces the entry already indexed under its ID.
+func (x *TitleIndex) Put(e Suggestion) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if old, ok := x.entries[e.ID]; ok {
+		removeKeys(x.root, old)
+	}
+	x.entries[e.ID] = e
+	insertKeys(x.root, e)
+}
+
+// Remove drops a movie from the index.
+func (x *TitleIndex) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if old, ok := x.entries[id]; ok {
+		removeKeys(x.root, old)
+		delete(x.entries, id)
+	}
+}
+
+// Suggest returns up to limit movies with a word sequence starting with prefix.
+// Titles that start with the prefix come first, then shorter titles, then
+// alphabetical order. Only the branches that can still place a movie in the
+// top limit are visited, so short prefixes do not walk the whole catalogue.
+func (x *TitleIndex) Suggest(prefix string, limit int) []Suggestion {
+	key := strings.Join(Terms(prefix), " ")
+	if key == "" || limit <= 0 {
+		return nil
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	node := x.root
+	for _, r := range key {
+		if node = node.children[r]; node == nil {
+			return nil
+		}
+	}
+
+	results := x.ranked(node, limit, true, nil)
+	if len(results) < limit {
+		// Every title starting with the prefix is in results; fill up with
+		// the titles matching at a later word.
+		skip := make(map[string]bool, len(results))
+		for _, r := range results {
+			skip[r.ID] = true
+		}
+		results = append(results, x.ranked(node, limit-len(results), false, skip)...)
+	}
+	return results
+}
+
+// ranked returns the best limit movies indexed at or below n, by title length,
+// folded title and ID, leaving out those in skip. With start set only keys
+// from the start of a title count. Nodes are visited shortest bound first; the
+// walk ends when the next bound is longer than the limit-th title found.
+func (x *TitleIndex) ranked(n *trieNode, limit int, start bool, skip map[string]bool) []Suggestion {
+	bound := func(n *trieNode) int {
+		if start {
+			return n.minStart
+		}
+		return n.minAny
+	}
+	queue := &nodeQueue{bound: bound}
+	if bound(n) != math.MaxInt {
+		heap.Push(queue, n)
+	}
+
+	seen := make(map[string]bool)
+	var found []Suggestion
+	for queue.Len() > 0 {
+		next := heap.Pop(queue).(*trieNode)
+		if len(found) == limit && bound(next) > len(found[limit-1].Title) {
+			break
+		}
+		for id, p := range next.ids {
+			if (start && p.pos != 0) || skip[id] || seen[id] {
+				continue
+			}
+			seen[id] = true
+			found = append(found, x.entries[id])
+		}
+		for _, child := range next.children {
+			if bound(child) != math.MaxInt {
+				heap.Push(queue, child)
+			}
+		}
+		if len(found) >= limit {
+			sortSuggestions(found)
+			found = found[:limit]
+		}
+	}
+	sortSuggestions(found)
+	return found
+}
+
+func sortSuggestions(s []Suggestion) {
+	sort.Slice(s, func(i, j int) bool {
+		a, b := s[i], s[j]
+		if len(a.Title) != len(b.Title) {
+			return len(a.Title) < len(b.Title)
+		}
+		if fa, fb := Fold(a.Title), Fold(b.Title); fa != fb {
+			return fa < fb
+		}
+		return a.ID < b.ID
+	})
+}
+
+// nodeQueue is a min-heap of trie nodes by bound.
+type nodeQueue struct {
+	nodes []*trieNode
+	bound func(*trieNode) int
+}
+
+func (q *nodeQueue) Len() int           { return len(q.nodes) }
+func (q *nodeQueue) Less(i, j int) bool { return q.bound(q.nodes[i]) < q.bound(q.nodes[j]) }
+func (q *nodeQueue) Swap(i, j int)      { q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i] }
+func (q *nodeQueue) Push(v any)         { q.nodes = append(q.nodes, v.(*trieNode)) }
+func (q *nodeQueue) Pop() any {
+	n := q.nodes[len(q.nodes)-1]
+	q.nodes = q.nodes[:len(q.nodes)-1]
+	return n
+}
+
+// titleKeys returns the keys a title is indexed under: its folded words from
+// each word onwards.
+func titleKeys(title string) []string {
+	terms := Terms(title)
+	keys := make([]string, len(terms))
+	for i := range terms {
+		keys[i] = strings.Join(terms[i:], " ")
+	}
+	return keys
+}
+
+func insertKeys(root *trieNode, e Suggestion) {
+	for pos, key := range titleKeys(e.Title) {
+		p := posting{pos: pos, length: len(e.Title)}
+		node := root
+		node.include(p)
+		for _, r := range key {
+			child := node.children[r]
+			if child == nil {
+				child = newTrieNode()
+				node.children[r] = child
+			}
+			node = child
+			node.include(p)
+		}
+		if node.ids == nil {
+			node.ids = make(map[string]posting)
+		}
+		if _, ok := node.ids[e.ID]; !ok {
+			node.ids[e.ID] = p
+		}
+	}
+}
+
+// removeKeys deletes e's keys and prunes the branches left empty.
+func removeKeys(root *trieNode, e Suggestion) {
+	for _, key := range titleKeys(e.Title) {
+		removeKey(root, []rune(key), e.ID)
+	}
+}
+
+// removeKey deletes id under key below n, updating the bounds on the way back
+// up, and reports whether n is now empty.
+func removeKey(n *trieNode, key []rune, id string) bool {
+	if len(key) == 0 {
+		delete(n.ids, id)
+	} else if child := n.children[key[0]]; child != nil && removeKey(child, key[1:], id) {
+		delete(n.children, key[0])
+	}
+	n.updateBounds()
+	return len(n.ids) == 0 && len(n.children) == 0
+}
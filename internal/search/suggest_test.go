@@ -0,0 +1,226 @@
+package search
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func suggestedIDs(x *TitleIndex, prefix string, limit int) []string {
+	var ids []string
+	for _, s := range x.Suggest(prefix, limit) {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func TestTitleIndexSuggest(t *testing.T) {
+	x := NewTitleIndex()
+	x.Load([]Suggestion{
+		{ID: "1", Title: "The Dark Knight", ReleaseYear: 2008},
+		{ID: "2", Title: "The Dark Knight Rises", ReleaseYear: 2012},
+		{ID: "3", Title: "Knight and Day", ReleaseYear: 2010},
+		{ID: "4", Title: "Amélie", ReleaseYear: 2001},
+		{ID: "5", Title: "Dark", ReleaseYear: 2017},
+	})
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"title prefix ranks first", "knight", 10, []string{"3", "1", "2"}},
+		{"shorter titles first", "the dark", 10, []string{"1", "2"}},
+		{"word sequence", "dark kn", 10, []string{"1", "2"}},
+		{"start of title before later words", "dark", 10, []string{"5", "1", "2"}},
+		{"folded prefix", "AMEL", 10, []string{"4"}},
+		{"accented prefix", "amél", 10, []string{"4"}},
+		{"punctuation ignored", "  the, dark!", 10, []string{"1", "2"}},
+		{"limit", "knight", 2, []string{"3", "1"}},
+		{"no match", "zzz", 10, nil},
+		{"blank prefix", " ", 10, nil},
+		{"zero limit", "dark", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggestedIDs(x, tt.prefix, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suggest(%q, %d) = %v, want %v", tt.prefix, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitleIndexWrites(t *testing.T) {
+	type query struct {
+		prefix string
+		want   []string
+	}
+	tests := []struct {
+		name    string
+		apply   func(x *TitleIndex)
+		len     int
+		queries []query
+	}{
+		{
+			name:  "put adds",
+			apply: func(x *TitleIndex) { x.Put(Suggestion{ID: "3", Title: "Alien", ReleaseYear: 1979}) },
+			len:   3,
+			queries: []query{
+				{"ali", []string{"3", "1"}},
+			},
+		},
+		{
+			name:  "remove drops every key",
+			apply: func(x *TitleIndex) { x.Remove("1") },
+			len:   1,
+			queries: []query{
+				{"aliens", nil},
+				{"alien", nil},
+				{"heat", []string{"2"}},
+			},
+		},
+		{
+			name:  "remove unknown id",
+			apply: func(x *TitleIndex) { x.Remove("9") },
+			len:   2,
+			queries: []query{
+				{"aliens", []string{"1"}},
+			},
+		},
+		{
+			name:  "rename replaces the old keys",
+			apply: func(x *TitleIndex) { x.Put(Suggestion{ID: "1", Title: "Heat Wave", ReleaseYear: 1986}) },
+			len:   2,
+			queries: []query{
+				{"aliens", nil},
+				{"heat", []string{"2", "1"}},
+				{"wave", []string{"1"}},
+			},
+		},
+		{
+			name: "rename to a title sharing a key",
+			apply: func(x *TitleIndex) {
+				x.Put(Suggestion{ID: "2", Title: "Heat Heat", ReleaseYear: 1995})
+				x.Put(Suggestion{ID: "2", Title: "Heat", ReleaseYear: 1995})
+			},
+			len: 2,
+			queries: []query{
+				{"heat", []string{"2"}},
+				{"heat h", nil},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := NewTitleIndex()
+			x.Load([]Suggestion{
+				{ID: "1", Title: "Aliens", ReleaseYear: 1986},
+				{ID: "2", Title: "Heat", ReleaseYear: 1995},
+			})
+			tt.apply(x)
+			if got := len(x.entries); got != tt.len {
+				t.Errorf("indexed %d movies, want %d", got, tt.len)
+			}
+			for _, q := range tt.queries {
+				if got := suggestedIDs(x, q.prefix, 10); !reflect.DeepEqual(got, q.want) {
+					t.Errorf("Suggest(%q) = %v, want %v", q.prefix, got, q.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTitleIndexRemovePrunes(t *testing.T) {
+	x := NewTitleIndex()
+	x.Put(Suggestion{ID: "1", Title: "The Thing"})
+	x.Put(Suggestion{ID: "2", Title: "The Thin Red Line"})
+	x.Remove("1")
+	x.Remove("2")
+	if n := len(x.root.children); n != 0 {
+		t.Errorf("root still has %d children after removing every movie", n)
+	}
+}
+
+// bruteSuggest ranks every matching entry, which is what Suggest must agree
+// with without visiting them all.
+func bruteSuggest(x *TitleIndex, prefix string, limit int) []string {
+	want := strings.Join(Terms(prefix), " ")
+	type hit struct {
+		s     Suggestion
+		first bool
+	}
+	var hits []hit
+	for _, e := range x.entries {
+		for pos, key := range titleKeys(e.Title) {
+			if strings.HasPrefix(key, want) {
+				hits = append(hits, hit{e, pos == 0})
+				break
+			}
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.first != b.first {
+			return a.first
+		}
+		if len(a.s.Title) != len(b.s.Title) {
+			return len(a.s.Title) < len(b.s.Title)
+		}
+		if fa, fb := Fold(a.s.Title), Fold(b.s.Title); fa != fb {
+			return fa < fb
+		}
+		return a.s.ID < b.s.ID
+	})
+	var ids []string
+	for i := 0; i < len(hits) && i < limit; i++ {
+		ids = append(ids, hits[i].s.ID)
+	}
+	return ids
+}
+
+func TestTitleIndexSuggestMatchesFullRanking(t *testing.T) {
+	words := []string{"a", "an", "dark", "day", "dawn", "the", "then", "knight", "kn", "d", "da"}
+	rng := rand.New(rand.NewSource(1))
+	title := func() string {
+		n := 1 + rng.Intn(4)
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = words[rng.Intn(len(words))]
+		}
+		return strings.Join(parts, " ")
+	}
+
+	x := NewTitleIndex()
+	var entries []Suggestion
+	for i := 0; i < 300; i++ {
+		entries = append(entries, Suggestion{ID: fmt.Sprintf("%03d", i), Title: title()})
+	}
+	x.Load(entries)
+
+	check := func(stage string) {
+		t.Helper()
+		for _, prefix := range []string{"d", "da", "dar", "the", "the d", "kn", "a", "an d", "x"} {
+			for _, limit := range []int{1, 3, 10, 50, 1000} {
+				got, want := suggestedIDs(x, prefix, limit), bruteSuggest(x, prefix, limit)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: Suggest(%q, %d) = %v, want %v", stage, prefix, limit, got, want)
+				}
+			}
+		}
+	}
+	check("after load")
+
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("%03d", rng.Intn(len(entries)))
+		if rng.Intn(2) == 0 {
+			x.Remove(id)
+		} else {
+			x.Put(Suggestion{ID: id, Title: title()})
+		}
+	}
+	check("after writes")
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFoldAndTerms(t *testing.T) {
+	tests := []struct {
+		in    string
+		fold  string
+		terms []string
+	}{
+		{"Amélie", "amelie", []string{"amelie"}},
+		{"LÉON: The Professional", "leon: the professional", []string{"leon", "the", "professional"}},
+		{"Straße  nach Œdipus", "strasse  nach oedipus", []string{"strasse", "nach", "oedipus"}},
+		{"Se7en", "se7en", []string{"se7en"}},
+		{"WALL·E", "wall·e", []string{"wall", "e"}},
+		{" -- ", " -- ", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := Fold(tt.in); got != tt.fold {
+				t.Errorf("Fold = %q, want %q", got, tt.fold)
+			}
+			if got := Terms(tt.in); !reflect.DeepEqual(got, tt.terms) {
+				t.Errorf("Terms = %q, want %q", got, tt.terms)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"inception", "inception", 0},
+		{"incepton", "inception", 1},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"amélie", "amelie", 1}, // runes, not bytes
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Distance(tt.b, tt.a); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name        string
+		query, text string
+		want        float64
+	}{
+		{"exact", "Inception", "Inception", 1},
+		{"case and accents", "amelie", "Amélie", 1},
+		{"word order ignored", "knight dark", "The Dark Knight", 1},
+		{"one typo", "incepton", "Inception", 1 - 1.0/9},
+		{"typos averaged over terms", "drak knight", "The Dark Knight", (1 - 2.0/4 + 1) / 2},
+		{"best word wins", "dark", "Dark Dork", 1},
+		{"unrelated", "xyz", "abc", 0},
+		{"empty query", "", "Inception", 0},
+		{"punctuation only", "!!", "Inception", 0},
+		{"empty text", "Inception", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Similarity(tt.query, tt.text); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Similarity(%q, %q) = %v, want %v", tt.query, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarityRanksCloserTitlesHigher(t *testing.T) {
+	query := "godfater"
+	closer, further := Similarity(query, "The Godfather"), Similarity(query, "The Gold Father")
+	if closer <= further {
+		t.Errorf("Similarity(%q) = %v for The Godfather, %v for The Gold Father; want the first higher", query, closer, further)
+	}
+}
@@ -2,9 +2,12 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -13,6 +16,8 @@ import (
 	"github.com/robin-camp/movies/internal/api/middleware"
 	"github.com/robin-camp/movies/internal/clients/boxoffice"
 	"github.com/robin-camp/movies/internal/config"
+	"github.com/robin-camp/movies/internal/enrichment"
+	"github.com/robin-camp/movies/internal/search"
 	"github.com/robin-camp/movies/internal/store"
 )
 
@@ -21,30 +26,102 @@ type Server struct {
 	httpServer *http.Server
 	logger     *slog.Logger
 	db         *store.DB
+	movieStore *store.MovieStore
+	titles     *search.TitleIndex
+	workers    *enrichment.Pool
+	refresher  *enrichment.Refresher
+	cache      *boxoffice.MySQLCache // nil unless the mysql lookup cache is configured
+	background sync.WaitGroup
 }
 
-// New wires a chi router and prepares the HTTP server instance.
-func New(cfg config.Config, db *store.DB, logger *slog.Logger) *Server {
+// New wires a chi router and prepares the HTTP server instance. It fails when a
+// configured box office provider cannot be loaded.
+func New(cfg config.Config, db *store.DB, logger *slog.Logger) (*Server, error) {
 	router := chi.NewRouter()
 	router.Use(middleware.Logger(logger))
 
-	// Health check
-	router.Get("/healthz", handlers.HealthCheck(db))
-
 	// Box office client
-	boClient := boxoffice.NewClient(cfg.BoxOfficeURL, cfg.BoxOfficeKey, logger)
+	boClient := boxoffice.NewClient(cfg.BoxOfficeURL, cfg.BoxOfficeKey, boxoffice.Options{
+		BreakerThreshold: cfg.BoxOfficeBreakerThreshold,
+		BreakerCooldown:  cfg.BoxOfficeBreakerCooldown,
+		MaxConcurrency:   cfg.BoxOfficeMaxConcurrency,
+	}, logger)
+
+	var mysqlCache *boxoffice.MySQLCache
+	if cfg.BoxOfficeCache == "mysql" {
+		mysqlCache = boxoffice.NewMySQLCache(db.DB)
+	}
+	provider, err := newProvider(cfg, mysqlCache, boClient, logger)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("box office provider configured", "provider", provider.Name(), "strategy", cfg.BoxOfficeMergeStrategy)
+
+	// Health check
+	router.Get("/healthz", handlers.HealthCheck(db, boClient))
 
 	// Stores
 	movieStore := store.NewMovieStore(db)
 	ratingStore := store.NewRatingStore(db)
+	jobStore := store.NewJobStore(db)
+	rateStore := store.NewExchangeRateStore(db)
+	facetCache := store.NewFacetCache(movieStore, cfg.FacetCacheTTL)
+
+	// Box office enrichment, inline or through the job queue
+	enricher := enrichment.NewEnricher(movieStore, provider, logger)
+	workers := enrichment.NewPool(jobStore, movieStore, enricher, cfg.EnrichmentWorkers, cfg.EnrichmentMaxAttempts, cfg.BoxOfficeBreakerCooldown, logger)
+	refresher := enrichment.NewRefresher(db, movieStore, enricher, enrichment.RefresherConfig{
+		Interval:  cfg.BoxOfficeRefreshInterval,
+		MaxAge:    cfg.BoxOfficeRefreshMaxAge,
+		BatchSize: cfg.BoxOfficeRefreshBatch,
+		RPS:       cfg.BoxOfficeRefreshRPS,
+	}, logger)
+
+	// Title suggestions, loaded in Run
+	titles := search.NewTitleIndex()
+
+	// List pagination
+	cursorKey, err := cursorKey(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	listOpts := handlers.ListOptions{CursorKey: cursorKey, CountTimeout: cfg.TotalCountTimeout}
 
 	// Handlers
-	movieHandler := handlers.NewMovieHandler(movieStore, boClient, logger)
+	movieHandler := handlers.NewMovieHandler(movieStore, ratingStore, jobStore, rateStore, facetCache, enricher, titles, listOpts, logger)
 	ratingHandler := handlers.NewRatingHandler(movieStore, ratingStore, logger)
+	boxOfficeHandler := handlers.NewBoxOfficeHandler(movieStore, rateStore, enricher, listOpts, logger)
+	rateHandler := handlers.NewExchangeRateHandler(rateStore, logger)
 
 	// Movie routes
 	router.With(middleware.BearerAuth(cfg.AuthToken)).Post("/movies", movieHandler.Create)
 	router.Get("/movies", movieHandler.List)
+	router.Get("/movies/suggest", movieHandler.Suggest)
+	router.Get("/movies/{title}", movieHandler.Get)
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.BearerAuth(cfg.AuthToken))
+		r.Post("/movies:import", movieHandler.Import)
+		r.Get("/movies:export", movieHandler.Export)
+		r.Put("/movies/{title}", movieHandler.Replace)
+		r.Patch("/movies/{title}", movieHandler.Patch)
+		r.Delete("/movies/{title}", movieHandler.Delete)
+	})
+
+	// Box office routes
+	router.Get("/movies/{title}/boxoffice/history", boxOfficeHandler.History)
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.BearerAuth(cfg.AuthToken))
+		r.Post("/movies/boxoffice:refresh", boxOfficeHandler.RefreshMany)
+		r.Post("/movies/{title}/boxoffice:refresh", boxOfficeHandler.Refresh)
+	})
+
+	// Exchange rate admin routes
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.BearerAuth(cfg.AuthToken))
+		r.Get("/exchange-rates", rateHandler.List)
+		r.Put("/exchange-rates/{currency}/{date}", rateHandler.Put)
+		r.Delete("/exchange-rates/{currency}/{date}", rateHandler.Delete)
+	})
 
 	// Rating routes
 	router.With(middleware.RequireRaterID).Post("/movies/{title}/ratings", ratingHandler.SubmitRating)
@@ -58,11 +135,82 @@ func New(cfg config.Config, db *store.DB, logger *slog.Logger) *Server {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	return &Server{httpServer: srv, logger: logger, db: db}
+	return &Server{
+		httpServer: srv,
+		logger:     logger,
+		db:         db,
+		movieStore: movieStore,
+		titles:     titles,
+		workers:    workers,
+		refresher:  refresher,
+		cache:      mysqlCache,
+	}, nil
+}
+
+// newProvider assembles the configured box office providers in priority order,
+// combining them under the merge strategy when there is more than one. The
+// upstream API is put behind the configured lookup cache; the local sources are
+// already in memory.
+func newProvider(cfg config.Config, mysqlCache *boxoffice.MySQLCache, client *boxoffice.Client, logger *slog.Logger) (boxoffice.Provider, error) {
+	strategy, err := boxoffice.ParseMergeStrategy(cfg.BoxOfficeMergeStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]boxoffice.Provider, 0, len(cfg.BoxOfficeProviders))
+	for _, name := range cfg.BoxOfficeProviders {
+		switch name {
+		case "apifox":
+			providers = append(providers, cachedProvider(cfg, mysqlCache, client, logger))
+		case "csv":
+			p, err := boxoffice.LoadCSVProvider(cfg.BoxOfficeCSVPath)
+			if err != nil {
+				return nil, fmt.Errorf("load csv box office provider: %w", err)
+			}
+			providers = append(providers, p)
+		case "static":
+			p, err := boxoffice.LoadStaticProvider(cfg.BoxOfficeFixturePath)
+			if err != nil {
+				return nil, fmt.Errorf("load static box office provider: %w", err)
+			}
+			providers = append(providers, p)
+		default:
+			return nil, fmt.Errorf("unknown box office provider %q", name)
+		}
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return boxoffice.NewMultiProvider(strategy, providers...), nil
 }
 
-// Run starts the HTTP server and blocks until context cancellation or server failure.
+// Run loads the title suggestion index, starts the HTTP server and the
+// background enrichment (job workers and the box office refresher), and blocks
+// until context cancellation or server failure. Background work is drained
+// before Run returns.
 func (s *Server) Run(ctx context.Context) error {
+	if err := s.loadTitles(ctx); err != nil {
+		return fmt.Errorf("load title index: %w", err)
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	s.workers.Start(workerCtx)
+	s.refresher.Start(workerCtx)
+	if s.cache != nil {
+		s.background.Add(1)
+		go func() {
+			defer s.background.Done()
+			s.purgeCache(workerCtx)
+		}()
+	}
+	defer func() {
+		stopWorkers()
+		s.workers.Wait()
+		s.refresher.Wait()
+		s.background.Wait()
+	}()
+
 	errCh := make(chan error, 1)
 	go func() {
 		s.logger.Info("http server starting", "addr", s.httpServer.Addr)
@@ -89,3 +237,80 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 }
+
+// cursorKey returns the key pagination cursors are signed with, generating a
+// random one when none is configured.
+func cursorKey(cfg config.Config, logger *slog.Logger) ([]byte, error) {
+	if cfg.CursorSecret != "" {
+		return []byte(cfg.CursorSecret), nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate cursor key: %w", err)
+	}
+	logger.Warn("CURSOR_SECRET not set; using a random key, cursors will not survive restarts")
+	return key, nil
+}
+
+// loadTitles fills the title suggestion index from the movies table.
+func (s *Server) loadTitles(ctx context.Context) error {
+	movies, err := s.movieStore.ListTitles(ctx)
+	if err != nil {
+		return err
+	}
+	entries := make([]search.Suggestion, len(movies))
+	for i := range movies {
+		entries[i] = movies[i].Suggestion()
+	}
+	s.titles.Load(entries)
+	s.logger.Info("title index loaded", "movies", len(entries))
+	return nil
+}
+
+// cachePurgeInterval is how often expired box_office_cache rows are deleted.
+const cachePurgeInterval = 10 * time.Minute
+
+// purgeCache deletes expired lookup cache rows every cachePurgeInterval until
+// ctx is cancelled. Replicas purge independently; deletes are idempotent.
+func (s *Server) purgeCache(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cachePurgeInterval):
+		}
+		n, err := s.cache.Purge(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error("failed to purge box office cache", "err", err)
+			continue
+		}
+		if n > 0 {
+			s.logger.Info("box office cache purged", "rows", n)
+		}
+	}
+}
+
+// cachedProvider wraps p in the configured read-through cache; mysqlCache is
+// the backend when BOXOFFICE_CACHE is mysql. With the cache set to none, misses
+// are still kept in memory for BOXOFFICE_NOT_FOUND_TTL so unknown titles are
+// not looked up again on every request.
+func cachedProvider(cfg config.Config, mysqlCache *boxoffice.MySQLCache, p boxoffice.Provider, logger *slog.Logger) boxoffice.Provider {
+	opts := boxoffice.CacheOptions{
+		TTL:         cfg.BoxOfficeCacheTTL,
+		NotFoundTTL: cfg.BoxOfficeNotFoundTTL,
+	}
+	var cache boxoffice.Cache
+	switch cfg.BoxOfficeCache {
+	case "mysql":
+		cache = mysqlCache
+	case "memory":
+		cache = boxoffice.NewMemoryCache(cfg.BoxOfficeCacheSize)
+	default:
+		if opts.NotFoundTTL <= 0 {
+			return p
+		}
+		cache = boxoffice.NewMemoryCache(cfg.BoxOfficeCacheSize)
+		opts.TTL = 0
+	}
+	return boxoffice.NewCachedProvider(p, cache, opts, logger)
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/robin-camp/movies/internal/clients/boxoffice"
+	"github.com/robin-camp/movies/internal/config"
+)
+
+// fakeProvider knows only Alien and counts lookups per title.
+type fakeProvider struct {
+	calls map[string]int
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) GetByTitle(ctx context.Context, title string) (*boxoffice.Response, error) {
+	p.calls[title]++
+	if title == "Alien" {
+		return &boxoffice.Response{Title: title}, nil
+	}
+	return nil, nil
+}
+
+func TestCachedProviderWithoutLookupCache(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tests := []struct {
+		name        string
+		notFoundTTL time.Duration
+		misses      int // upstream lookups for the unknown title
+	}{
+		{"misses still cached", 10 * time.Minute, 1},
+		{"not-found TTL zero", 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := &fakeProvider{calls: make(map[string]int)}
+			cfg := config.Config{
+				BoxOfficeCache:       "none",
+				BoxOfficeCacheSize:   10,
+				BoxOfficeCacheTTL:    time.Hour,
+				BoxOfficeNotFoundTTL: tt.notFoundTTL,
+			}
+			p := cachedProvider(cfg, nil, upstream, logger)
+			for i := 0; i < 3; i++ {
+				for _, title := range []string{"Alien", "Unknown"} {
+					if _, err := p.GetByTitle(context.Background(), title); err != nil {
+						t.Fatalf("GetByTitle(%q): %v", title, err)
+					}
+				}
+			}
+			if got := upstream.calls["Alien"]; got != 3 {
+				t.Errorf("found title looked up %d times, want 3 with the lookup cache off", got)
+			}
+			if got := upstream.calls["Unknown"]; got != tt.misses {
+				t.Errorf("unknown title looked up %d times, want %d", got, tt.misses)
+			}
+		})
+	}
+}
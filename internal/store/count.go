@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// errQueryTimeout is MySQL's ER_QUERY_TIMEOUT, raised when MAX_EXECUTION_TIME
+// interrupts a statement.
+const errQueryTimeout = 3024
+
+// Total is the number of movies matching a filter set. Exact is false when
+// counting took too long and the optimizer's row estimate was used instead.
+type Total struct {
+	Count int64
+	Exact bool
+}
+
+// Count counts the movies matching filters, ignoring sort, cursor and limit.
+// With a positive timeout the exact count is abandoned after that long and the
+// query plan's row estimate for the movies table is returned instead.
+func (s *MovieStore) Count(ctx context.Context, filters ListFilters, timeout time.Duration) (Total, error) {
+	from, args := filters.fromWhere("", searchMatch, filters.Query)
+
+	hint := ""
+	if timeout > 0 {
+		hint = `/*+ MAX_EXECUTION_TIME(` + strconv.FormatInt(max(timeout.Milliseconds(), 1), 10) + `) */ `
+	}
+	var count int64
+	err := s.db.GetContext(ctx, &count, `SELECT `+hint+`COUNT(*)`+from, args...)
+	if err == nil {
+		return Total{Count: count, Exact: true}, nil
+	}
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != errQueryTimeout {
+		return Total{}, err
+	}
+
+	estimate, err := s.estimateRows(ctx, `SELECT m.id`+from, args)
+	if err != nil {
+		return Total{}, err
+	}
+	return Total{Count: estimate}, nil
+}
+
+// estimateRows returns the optimizer's estimate of how many movies rows query
+// yields: the rows it expects to read from the m table times the share it
+// expects the conditions to keep.
+func (s *MovieStore) estimateRows(ctx context.Context, query string, args []interface{}) (int64, error) {
+	rows, err := s.db.QueryxContext(ctx, `EXPLAIN `+query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		plan := make(map[string]interface{})
+		if err := rows.MapScan(plan); err != nil {
+			return 0, err
+		}
+		if explainString(plan["table"]) != "m" {
+			continue
+		}
+		estimate := explainNumber(plan["rows"]) * explainNumber(plan["filtered"]) / 100
+		return int64(estimate), nil
+	}
+	return 0, rows.Err()
+}
+
+// explainString and explainNumber read EXPLAIN columns, which arrive as text
+// or as typed values depending on whether the statement had arguments.
+func explainString(v interface{}) string {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	}
+	return ""
+}
+
+func explainNumber(v interface{}) float64 {
+	switch v := v.(type) {
+	case []byte:
+		f, _ := strconv.ParseFloat(string(v), 64)
+		return f
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testCursorKey = []byte("test-cursor-key")
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"forward", Cursor{Sort: SortCreatedAt, Value: "2024-01-02 03:04:05.000000", ID: "01HZX"}},
+		{"descending", Cursor{Sort: SortBudget, Desc: true, Value: "1000000", ID: "01HZY"}},
+		{"before", Cursor{Sort: SortTitle, Before: true, Value: "Alien", ID: "01HZZ"}},
+		{"empty value", Cursor{Sort: SortWorldwideGross, Desc: true, Before: true, Value: "", ID: "01J00"}},
+		{"punctuation", Cursor{Sort: SortTitle, Value: `Léon: "The Professional" .v1.`, ID: "01J01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := EncodeCursor(tt.cursor, testCursorKey)
+			if err != nil {
+				t.Fatalf("EncodeCursor: %v", err)
+			}
+			if !strings.HasPrefix(s, cursorVersion+".") {
+				t.Errorf("cursor %q lacks the %s prefix", s, cursorVersion)
+			}
+			got, err := DecodeCursor(s, testCursorKey)
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if *got != tt.cursor {
+				t.Errorf("got %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorEmpty(t *testing.T) {
+	c, err := DecodeCursor("", testCursorKey)
+	if c != nil || err != nil {
+		t.Errorf("DecodeCursor(\"\") = %v, %v, want nil, nil", c, err)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	valid, err := EncodeCursor(Cursor{Sort: SortTitle, Value: "Alien", ID: "01HZX"}, testCursorKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+	// sign builds a correctly signed cursor around an arbitrary payload.
+	sign := func(version, payload string) string {
+		signed := version + "." + base64.RawURLEncoding.EncodeToString([]byte(payload))
+		return signed + "." + base64.RawURLEncoding.EncodeToString(cursorMAC(signed, testCursorKey))
+	}
+	tampered, _ := EncodeCursor(Cursor{Sort: SortTitle, Value: "Zodiac", ID: "01HZX"}, testCursorKey)
+
+	tests := []struct {
+		name   string
+		cursor string
+		key    []byte
+	}{
+		{"other key", valid, []byte("another-key")},
+		{"no separator", "garbage", testCursorKey},
+		{"swapped payload", parts[0] + "." + strings.Split(tampered, ".")[1] + "." + parts[2], testCursorKey},
+		{"truncated signature", valid[:len(valid)-2], testCursorKey},
+		{"signature not base64", parts[0] + "." + parts[1] + ".!!!", testCursorKey},
+		{"missing signature", parts[0] + "." + parts[1], testCursorKey},
+		{"unknown version", "v2." + parts[1] + "." + parts[2], testCursorKey},
+		{"resigned under other version", sign("v0", `{"s":"title","v":"Alien","i":"01HZX"}`), testCursorKey},
+		{"payload not JSON", sign(cursorVersion, "not json"), testCursorKey},
+		{"unknown sort", sign(cursorVersion, `{"s":"popularity","v":"1","i":"01HZX"}`), testCursorKey},
+		{"missing id", sign(cursorVersion, `{"s":"title","v":"Alien"}`), testCursorKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := DecodeCursor(tt.cursor, tt.key)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) = %+v, %v, want ErrInvalidCursor", tt.cursor, c, err)
+			}
+		})
+	}
+}
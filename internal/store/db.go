@@ -75,3 +75,29 @@ func (d *DB) InTx(ctx context.Context, fn func(*sqlx.Tx) error) error {
 
 	return tx.Commit()
 }
+
+// TryLock acquires a MySQL named lock (GET_LOCK) without waiting. The lock lives
+// on a dedicated connection, so it is held until release is called or the
+// connection dies. ok is false when another session holds the lock.
+func (d *DB) TryLock(ctx context.Context, name string) (release func(), ok bool, err error) {
+	conn, err := d.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, 0)`, name).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return nil, false, err
+	}
+	if acquired.Int64 != 1 {
+		_ = conn.Close()
+		return nil, false, nil
+	}
+
+	release = func() {
+		_, _ = conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, name)
+		_ = conn.Close()
+	}
+	return release, true, nil
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Facets MovieStore.Facets can count.
+const (
+	FacetGenre       = "genre"
+	FacetMPARating   = "mpaRating"
+	FacetDistributor = "distributor"
+	FacetDecade      = "decade"
+)
+
+// facetExprs are the values each facet groups by. Movies where the value is
+// NULL are not counted.
+var facetExprs = map[string]string{
+	FacetGenre:       "m.genre",
+	FacetMPARating:   "m.mpa_rating",
+	FacetDistributor: "m.distributor",
+	FacetDecade:      "CONCAT(YEAR(m.release_date) DIV 10 * 10, 's')",
+}
+
+// ValidFacet reports whether name is a facet Facets understands.
+func ValidFacet(name string) bool {
+	_, ok := facetExprs[name]
+	return ok
+}
+
+// FacetCount is the number of matching movies sharing one facet value.
+type FacetCount struct {
+	Value string `db:"value" json:"value"`
+	Count int64  `db:"count" json:"count"`
+}
+
+// Facets counts the movies matching filters per value of each named facet,
+// most frequent first. Sort, cursor and limit do not apply; every match is
+// counted. All facets are computed in a single query.
+func (s *MovieStore) Facets(ctx context.Context, filters ListFilters, names []string) (map[string][]FacetCount, error) {
+	from, whereArgs := filters.fromWhere("", searchMatch, filters.Query)
+	return s.facets(ctx, from, whereArgs, names)
+}
+
+// FacetsOf counts the movies with the given IDs per value of each named facet,
+// for result sets that are not expressible as filters, such as the fuzzy
+// search fallback's page.
+func (s *MovieStore) FacetsOf(ctx context.Context, movieIDs []string, names []string) (map[string][]FacetCount, error) {
+	if len(movieIDs) == 0 {
+		return s.facets(ctx, ` FROM movies m WHERE 1=0`, nil, names)
+	}
+	from, whereArgs, err := sqlx.In(` FROM movies m WHERE m.id IN (?)`, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+	return s.facets(ctx, from, whereArgs, names)
+}
+
+// facets runs the facet query over the rows selected by from, a FROM and WHERE
+// clause that further "AND" conditions can extend.
+func (s *MovieStore) facets(ctx context.Context, from string, whereArgs []interface{}, names []string) (map[string][]FacetCount, error) {
+	result := make(map[string][]FacetCount, len(names))
+	if len(names) == 0 {
+		return result, nil
+	}
+
+	branches := make([]string, len(names))
+	var args []interface{}
+	for i, name := range names {
+		expr := facetExprs[name]
+		branches[i] = `SELECT ? AS facet, CAST(` + expr + ` AS CHAR) AS value, COUNT(*) AS count` +
+			from + ` AND ` + expr + ` IS NOT NULL GROUP BY value`
+		args = append(args, name)
+		args = append(args, whereArgs...)
+	}
+
+	var rows []struct {
+		Facet string `db:"facet"`
+		FacetCount
+	}
+	if err := s.db.SelectContext(ctx, &rows, strings.Join(branches, " UNION ALL "), args...); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		result[name] = []FacetCount{}
+	}
+	for _, row := range rows {
+		result[row.Facet] = append(result[row.Facet], row.FacetCount)
+	}
+	for _, counts := range result {
+		sort.Slice(counts, func(i, j int) bool {
+			if counts[i].Count != counts[j].Count {
+				return counts[i].Count > counts[j].Count
+			}
+			return counts[i].Value < counts[j].Value
+		})
+	}
+	return result, nil
+}
+
+// maxFacetCacheEntries bounds FacetCache; once full, new filter sets are
+// computed without being cached until expired entries are swept.
+const maxFacetCacheEntries = 1000
+
+// FacetCache keeps facet counts for a short time per filter set, so paging
+// through a result or repeating a popular browse does not recount the catalogue.
+// Counts may lag writes by up to the TTL.
+type FacetCache struct {
+	movieStore *MovieStore
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	entries map[string]facetEntry
+}
+
+type facetEntry struct {
+	counts  map[string][]FacetCount
+	expires time.Time
+}
+
+// NewFacetCache creates a FacetCache in front of ms; a zero ttl disables caching.
+func NewFacetCache(ms *MovieStore, ttl time.Duration) *FacetCache {
+	return &FacetCache{movieStore: ms, ttl: ttl, entries: make(map[string]facetEntry)}
+}
+
+// Facets returns cached counts for the filter set, computing them on a miss.
+// Callers must not modify the returned counts.
+func (c *FacetCache) Facets(ctx context.Context, filters ListFilters, names []string) (map[string][]FacetCount, error) {
+	if c.ttl <= 0 {
+		return c.movieStore.Facets(ctx, filters, names)
+	}
+
+	key := facetCacheKey(filters, names)
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.counts, nil
+	}
+
+	counts, err := c.movieStore.Facets(ctx, filters, names)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxFacetCacheEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) < maxFacetCacheEntries {
+		c.entries[key] = facetEntry{counts: counts, expires: now.Add(c.ttl)}
+	}
+	return counts, nil
+}
+
+// facetCacheKey identifies the filter set and facets, ignoring the paging and
+// presentation fields that do not change the counts.
+func facetCacheKey(filters ListFilters, names []string) string {
+	filters.Sort, filters.Desc, filters.Limit, filters.Cursor = "", false, 0, nil
+	filters.Fields, filters.IncludeRating, filters.IncludeBoxOfficeHistory = nil, false, false
+	data, _ := json.Marshal(filters)
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",") + "|" + string(data)
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// Int64Range is an inclusive range filter; nil bounds are open.
+type Int64Range struct {
+	Min *int64
+	Max *int64
+}
+
+// IsSet reports whether either bound is present.
+func (r Int64Range) IsSet() bool {
+	return r.Min != nil || r.Max != nil
+}
+
+// appendRange adds the SQL conditions for r on column to query.
+func appendRange(query string, args []interface{}, column string, r Int64Range) (string, []interface{}) {
+	if r.Min != nil {
+		query += ` AND ` + column + ` >= ?`
+		args = append(args, *r.Min)
+	}
+	if r.Max != nil {
+		query += ` AND ` + column + ` <= ?`
+		args = append(args, *r.Max)
+	}
+	return query, args
+}
+
+// DateRange is a half-open range of calendar dates, From inclusive and Before
+// exclusive; nil bounds are open.
+type DateRange struct {
+	From   *time.Time
+	Before *time.Time
+}
+
+// Narrow intersects r with [from, before); nil arguments leave a bound as is.
+func (r DateRange) Narrow(from, before *time.Time) DateRange {
+	if from != nil && (r.From == nil || from.After(*r.From)) {
+		r.From = from
+	}
+	if before != nil && (r.Before == nil || before.Before(*r.Before)) {
+		r.Before = before
+	}
+	return r
+}
+
+// appendDateRange adds the SQL conditions for r on a DATE column. Bounds are
+// sent as plain dates so the comparison is sargable and independent of the
+// connection's time zone.
+func appendDateRange(query string, args []interface{}, column string, r DateRange) (string, []interface{}) {
+	if r.From != nil {
+		query += ` AND ` + column + ` >= ?`
+		args = append(args, r.From.Format("2006-01-02"))
+	}
+	if r.Before != nil {
+		query += ` AND ` + column + ` < ?`
+		args = append(args, r.Before.Format("2006-01-02"))
+	}
+	return query, args
+}
+
+// ValueSet matches a column against lists of accepted and rejected values.
+// Matching follows the column collation, so it is case-insensitive.
+type ValueSet struct {
+	In    []string
+	NotIn []string
+}
+
+// appendValueSet adds the SQL conditions for v on column. Rows where the
+// column is NULL are kept by exclusions, since they hold none of the values.
+func appendValueSet(query string, args []interface{}, column string, v ValueSet) (string, []interface{}) {
+	if len(v.In) > 0 {
+		query += ` AND ` + column + ` IN (` + placeholders(len(v.In)) + `)`
+		for _, val := range v.In {
+			args = append(args, val)
+		}
+	}
+	if len(v.NotIn) > 0 {
+		query += ` AND (` + column + ` IS NULL OR ` + column + ` NOT IN (` + placeholders(len(v.NotIn)) + `))`
+		for _, val := range v.NotIn {
+			args = append(args, val)
+		}
+	}
+	return query, args
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Enrichment job states.
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobDead    = "dead"
+)
+
+// EnrichmentJob represents a queued box office enrichment for one movie.
+type EnrichmentJob struct {
+	ID          int64      `db:"id"`
+	MovieID     string     `db:"movie_id"`
+	Status      string     `db:"status"`
+	Attempts    int        `db:"attempts"`
+	LastError   *string    `db:"last_error"`
+	RunAt       time.Time  `db:"run_at"`
+	LockedUntil *time.Time `db:"locked_until"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+}
+
+// EnrichmentStatus is the API view of a movie's latest enrichment job.
+type EnrichmentStatus struct {
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     *string    `json:"lastError,omitempty"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+}
+
+// ToStatus converts the job into its API representation.
+func (j *EnrichmentJob) ToStatus() *EnrichmentStatus {
+	status := &EnrichmentStatus{
+		Status:    j.Status,
+		Attempts:  j.Attempts,
+		LastError: j.LastError,
+	}
+	if j.Status == JobPending {
+		runAt := j.RunAt
+		status.NextAttemptAt = &runAt
+	}
+	return status
+}
+
+// JobStore handles enrichment job persistence.
+type JobStore struct {
+	db *DB
+}
+
+// NewJobStore creates a new JobStore.
+func NewJobStore(db *DB) *JobStore {
+	return &JobStore{db: db}
+}
+
+// enqueueJob schedules an enrichment job for a movie to run immediately.
+func enqueueJob(ctx context.Context, ext sqlx.ExecerContext, movieID string) error {
+	return enqueueJobs(ctx, ext, []string{movieID})
+}
+
+// enqueueJobs queues a job per movie with a single multi-row INSERT.
+func enqueueJobs(ctx context.Context, ext sqlx.ExecerContext, movieIDs []string) error {
+	if len(movieIDs) == 0 {
+		return nil
+	}
+	query := `INSERT INTO enrichment_jobs (movie_id, status) VALUES ` +
+		strings.TrimSuffix(strings.Repeat(`(?, ?), `, len(movieIDs)), ", ")
+	args := make([]interface{}, 0, 2*len(movieIDs))
+	for _, id := range movieIDs {
+		args = append(args, id, JobPending)
+	}
+	_, err := ext.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Claim leases the next runnable job for the given duration. Jobs whose lease has
+// expired (e.g. the worker crashed) are picked up again. Returns nil when idle.
+func (s *JobStore) Claim(ctx context.Context, lease time.Duration) (*EnrichmentJob, error) {
+	var job *EnrichmentJob
+	err := s.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		var candidate EnrichmentJob
+		query := `
+			SELECT id, movie_id, status, attempts, last_error, run_at, locked_until, created_at, updated_at
+			FROM enrichment_jobs
+			WHERE (status = ? AND run_at <= CURRENT_TIMESTAMP(6))
+			   OR (status = ? AND locked_until < CURRENT_TIMESTAMP(6))
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		`
+		if err := tx.GetContext(ctx, &candidate, query, JobPending, JobRunning); err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			UPDATE enrichment_jobs
+			SET status = ?, attempts = attempts + 1,
+			    locked_until = CURRENT_TIMESTAMP(6) + INTERVAL ? MICROSECOND
+			WHERE id = ?`,
+			JobRunning, lease.Microseconds(), candidate.ID)
+		if err != nil {
+			return err
+		}
+
+		candidate.Status = JobRunning
+		candidate.Attempts++
+		job = &candidate
+		return nil
+	})
+	return job, err
+}
+
+// Complete marks a job as done.
+func (s *JobStore) Complete(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE enrichment_jobs SET status = ?, last_error = NULL, locked_until = NULL WHERE id = ?`,
+		JobDone, id)
+	return err
+}
+
+// Retry returns a job to the queue to run again after the given delay.
+func (s *JobStore) Retry(ctx context.Context, id int64, delay time.Duration, lastErr string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE enrichment_jobs
+		SET status = ?, last_error = ?, locked_until = NULL,
+		    run_at = CURRENT_TIMESTAMP(6) + INTERVAL ? MICROSECOND
+		WHERE id = ?`,
+		JobPending, lastErr, delay.Microseconds(), id)
+	return err
+}
+
+// Defer returns a job to the queue like Retry but gives back the attempt its
+// claim used, for runs that never reached the upstream.
+func (s *JobStore) Defer(ctx context.Context, id int64, delay time.Duration, lastErr string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE enrichment_jobs
+		SET status = ?, last_error = ?, locked_until = NULL, attempts = GREATEST(attempts - 1, 0),
+		    run_at = CURRENT_TIMESTAMP(6) + INTERVAL ? MICROSECOND
+		WHERE id = ?`,
+		JobPending, lastErr, delay.Microseconds(), id)
+	return err
+}
+
+// Bury moves a job to the dead-letter state; it is never retried automatically.
+func (s *JobStore) Bury(ctx context.Context, id int64, lastErr string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE enrichment_jobs SET status = ?, last_error = ?, locked_until = NULL WHERE id = ?`,
+		JobDead, lastErr, id)
+	return err
+}
+
+// LatestForMovie returns the most recent enrichment job for a movie, or nil.
+func (s *JobStore) LatestForMovie(ctx context.Context, movieID string) (*EnrichmentJob, error) {
+	var job EnrichmentJob
+	query := `SELECT id, movie_id, status, attempts, last_error, run_at, locked_until, created_at, updated_at
+	          FROM enrichment_jobs WHERE movie_id = ? ORDER BY id DESC LIMIT 1`
+	err := s.db.GetContext(ctx, &job, query, movieID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
@@ -3,48 +3,212 @@ package store
 import (
 	"context"
 	"database/sql"
-	"encoding/base64"
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/robin-camp/movies/internal/search"
 )
 
-// Movie represents a movie record.
+var (
+	// ErrNotFound is returned when a mutation targets a movie that no longer exists.
+	ErrNotFound = errors.New("movie not found")
+	// ErrPreconditionFailed is returned when the stored updated_at no longer matches the caller's expectation.
+	ErrPreconditionFailed = errors.New("movie was modified concurrently")
+	// ErrDuplicateTitle is returned when a write would violate the unique title constraint.
+	ErrDuplicateTitle = errors.New("movie title already exists")
+)
+
+// Movie represents a movie record. FieldSources records where distributor, budget
+// and mpaRating came from; CanonicalURL is the stable, ID-based address of the
+// movie and is set by the API layer. Score is the search relevance, set only by
+// List when searching. BoxOfficeHistory is only loaded on request.
 type Movie struct {
-	ID          string     `db:"id" json:"id"`
-	Title       string     `db:"title" json:"title"`
-	ReleaseDate time.Time  `db:"release_date" json:"releaseDate"`
-	Genre       string     `db:"genre" json:"genre"`
-	Distributor *string    `db:"distributor" json:"distributor,omitempty"`
-	Budget      *int64     `db:"budget" json:"budget,omitempty"`
-	MPARating   *string    `db:"mpa_rating" json:"mpaRating,omitempty"`
-	CreatedAt   time.Time  `db:"created_at" json:"-"`
-	UpdatedAt   time.Time  `db:"updated_at" json:"-"`
-	BoxOffice   *BoxOffice `json:"boxOffice,omitempty"`
-}
-
-// BoxOffice represents box office data.
+	ID           string            `db:"id" json:"id"`
+	Title        string            `db:"title" json:"title"`
+	ReleaseDate  time.Time         `db:"release_date" json:"releaseDate"`
+	Genre        string            `db:"genre" json:"genre"`
+	Distributor  *string           `db:"distributor" json:"distributor,omitempty"`
+	Budget       *int64            `db:"budget" json:"budget,omitempty"`
+	MPARating    *string           `db:"mpa_rating" json:"mpaRating,omitempty"`
+	FieldSources FieldSources      `db:"field_sources" json:"fieldSources,omitempty"`
+	CreatedAt    time.Time         `db:"created_at" json:"-"`
+	UpdatedAt    time.Time         `db:"updated_at" json:"-"`
+	BoxOffice    *BoxOffice        `db:"-" json:"boxOffice,omitempty"`
+	Rating       *RatingAggregate  `db:"-" json:"rating,omitempty"`
+	Enrichment   *EnrichmentStatus `db:"-" json:"enrichment,omitempty"`
+	CanonicalURL string            `db:"-" json:"canonicalUrl,omitempty"`
+	Score        *float64          `db:"-" json:"score,omitempty"`
+
+	BoxOfficeHistory []BoxOfficeSnapshot `db:"-" json:"boxOfficeHistory,omitempty"`
+}
+
+// Suggestion returns the movie's entry in a title suggestion index.
+func (m *Movie) Suggestion() search.Suggestion {
+	return search.Suggestion{ID: m.ID, Title: m.Title, ReleaseYear: m.ReleaseDate.Year()}
+}
+
+// movieColumns lists the movies columns scanned into Movie, qualified with the m alias.
+const movieColumns = `m.id, m.title, m.release_date, m.genre, m.distributor, m.budget, m.mpa_rating,
+	m.field_sources, m.created_at, m.updated_at`
+
+// Provenance-tracked movie fields, named as in the API.
+const (
+	FieldDistributor = "distributor"
+	FieldBudget      = "budget"
+	FieldMPARating   = "mpaRating"
+)
+
+// Further movie fields ListFilters.Fields can select, named as in the API.
+const (
+	FieldID           = "id"
+	FieldTitle        = "title"
+	FieldReleaseDate  = "releaseDate"
+	FieldGenre        = "genre"
+	FieldFieldSources = "fieldSources"
+	FieldBoxOffice    = "boxOffice"
+)
+
+// fieldColumns maps selectable fields to the movies columns they are read from.
+// FieldBoxOffice has no column; it decides whether box office rows are loaded.
+var fieldColumns = map[string]string{
+	FieldID:           "m.id",
+	FieldTitle:        "m.title",
+	FieldReleaseDate:  "m.release_date",
+	FieldGenre:        "m.genre",
+	FieldDistributor:  "m.distributor",
+	FieldBudget:       "m.budget",
+	FieldMPARating:    "m.mpa_rating",
+	FieldFieldSources: "m.field_sources",
+}
+
+// ValidField reports whether name is a movie field ListFilters.Fields can select.
+func ValidField(name string) bool {
+	_, ok := fieldColumns[name]
+	return ok || name == FieldBoxOffice
+}
+
+// SourceUser marks a value supplied through the API; enrichment never overwrites it.
+const SourceUser = "user"
+
+// FieldSources maps a provenance-tracked field to the source of its current value:
+// SourceUser or the name of a box office provider. Fields without a value are absent.
+type FieldSources map[string]string
+
+// IsUser reports whether the field's value must be treated as user-owned. Values
+// stored before provenance was tracked have no entry and are treated as user-owned.
+func (fs FieldSources) IsUser(field string) bool {
+	source, ok := fs[field]
+	return !ok || source == SourceUser
+}
+
+// Scan implements sql.Scanner for the JSON column.
+func (fs *FieldSources) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*fs = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported field_sources type %T", src)
+	}
+	return json.Unmarshal(data, fs)
+}
+
+// Value implements driver.Valuer for the JSON column.
+func (fs FieldSources) Value() (driver.Value, error) {
+	if len(fs) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(fs)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// BoxOffice represents box office data. Amounts are in Currency; Conversion is
+// set when they were converted from the stored currency on request.
 type BoxOffice struct {
 	Revenue struct {
 		Worldwide         int64  `json:"worldwide"`
+		Domestic          *int64 `json:"domestic,omitempty"`
+		International     *int64 `json:"international,omitempty"`
 		OpeningWeekendUSA *int64 `json:"openingWeekendUSA,omitempty"`
 	} `json:"revenue"`
-	Currency    string    `json:"currency"`
-	Source      string    `json:"source"`
-	LastUpdated time.Time `json:"lastUpdated"`
+	TheaterCount   *int        `json:"theaterCount,omitempty"`
+	WeeksInRelease *int        `json:"weeksInRelease,omitempty"`
+	Currency       string      `json:"currency"`
+	Source         string      `json:"source"`
+	LastUpdated    time.Time   `json:"lastUpdated"`
+	Conversion     *Conversion `json:"conversion,omitempty"`
 }
 
-// BoxOfficeRow represents the database row for box office data.
+// BoxOfficeRow represents the database row for box office data. Amounts are in
+// the source's native Currency.
 type BoxOfficeRow struct {
 	MovieID           string    `db:"movie_id"`
-	GrossUSD          int64     `db:"gross_usd"`
+	Gross             int64     `db:"gross"`
+	Domestic          *int64    `db:"domestic"`
+	International     *int64    `db:"international"`
 	OpeningWeekendUSA *int64    `db:"opening_weekend_usa"`
+	TheaterCount      *int      `db:"theater_count"`
+	WeeksInRelease    *int      `db:"weeks_in_release"`
 	Currency          string    `db:"currency"`
 	Source            string    `db:"source"`
 	LastReported      time.Time `db:"last_reported"`
 	FetchedAt         time.Time `db:"fetched_at"`
 }
 
+// boxOfficeColumns lists the columns shared by movie_box_office and its snapshots.
+const boxOfficeColumns = `gross, domestic, international, opening_weekend_usa, theater_count, weeks_in_release, currency, source, last_reported`
+
+// ToBoxOffice converts the database row into its API representation.
+func (bo *BoxOfficeRow) ToBoxOffice() *BoxOffice {
+	out := &BoxOffice{
+		TheaterCount:   bo.TheaterCount,
+		WeeksInRelease: bo.WeeksInRelease,
+		Currency:       bo.Currency,
+		Source:         bo.Source,
+		LastUpdated:    bo.LastReported,
+	}
+	out.Revenue.Worldwide = bo.Gross
+	out.Revenue.Domestic = bo.Domestic
+	out.Revenue.International = bo.International
+	out.Revenue.OpeningWeekendUSA = bo.OpeningWeekendUSA
+	return out
+}
+
+// BoxOfficeSnapshot is one fetched set of box office figures. Every fetch
+// appends a snapshot, so the rows for a movie form its revenue history.
+type BoxOfficeSnapshot struct {
+	ID                int64       `db:"id" json:"-"`
+	MovieID           string      `db:"movie_id" json:"-"`
+	Gross             int64       `db:"gross" json:"worldwide"`
+	Domestic          *int64      `db:"domestic" json:"domestic,omitempty"`
+	International     *int64      `db:"international" json:"international,omitempty"`
+	OpeningWeekendUSA *int64      `db:"opening_weekend_usa" json:"openingWeekendUSA,omitempty"`
+	TheaterCount      *int        `db:"theater_count" json:"theaterCount,omitempty"`
+	WeeksInRelease    *int        `db:"weeks_in_release" json:"weeksInRelease,omitempty"`
+	Currency          string      `db:"currency" json:"currency"`
+	Source            string      `db:"source" json:"source"`
+	LastReported      time.Time   `db:"last_reported" json:"reportedAt"`
+	FetchedAt         time.Time   `db:"fetched_at" json:"fetchedAt"`
+	Conversion        *Conversion `db:"-" json:"conversion,omitempty"`
+}
+
 // Rating represents a movie rating.
 type Rating struct {
 	MovieID   string    `db:"movie_id"`
@@ -69,24 +233,148 @@ func NewMovieStore(db *DB) *MovieStore {
 	return &MovieStore{db: db}
 }
 
-// Create inserts a new movie.
-func (s *MovieStore) Create(ctx context.Context, movie *Movie) error {
+// CreateWithEnrichmentJob inserts a new movie and queues its box office
+// enrichment in the same transaction.
+func (s *MovieStore) CreateWithEnrichmentJob(ctx context.Context, movie *Movie) error {
+	return s.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		if err := insertMovie(ctx, tx, movie); err != nil {
+			return err
+		}
+		return enqueueJob(ctx, tx, movie.ID)
+	})
+}
+
+// CreateMany inserts a batch of new movies in one transaction with a single
+// multi-row INSERT and, when queueJobs is set, queues their enrichment. The
+// result holds one error per movie: nil when it was stored, ErrDuplicateTitle
+// when its title is taken, or a *RowError when the database rejected its
+// values. Movies with an error are skipped; err reports a failed batch.
+func (s *MovieStore) CreateMany(ctx context.Context, movies []*Movie, queueJobs bool) (result []error, err error) {
+	if len(movies) == 0 {
+		return nil, nil
+	}
+	err = s.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		result = make([]error, len(movies))
+
+		titles := make([]string, len(movies))
+		for i, m := range movies {
+			titles[i] = m.Title
+		}
+		query, args, err := sqlx.In(`SELECT title FROM movies WHERE title IN (?)`, titles)
+		if err != nil {
+			return err
+		}
+		var taken []string
+		if err := tx.SelectContext(ctx, &taken, tx.Rebind(query), args...); err != nil {
+			return err
+		}
+		takenSet := make(map[string]bool, len(taken))
+		for _, title := range taken {
+			takenSet[search.Fold(title)] = true
+		}
+
+		var fresh []*Movie
+		var freshIdx []int
+		for i, m := range movies {
+			if takenSet[search.Fold(m.Title)] {
+				result[i] = ErrDuplicateTitle
+				continue
+			}
+			fresh = append(fresh, m)
+			freshIdx = append(freshIdx, i)
+		}
+
+		// A failed statement only rolls itself back, so when one row breaks
+		// the multi-row INSERT the rows are retried one by one to find it.
+		err = insertMovies(ctx, tx, fresh)
+		if rowError(err) != nil {
+			err = nil
+			for j, m := range fresh {
+				insertErr := insertMovie(ctx, tx, m)
+				if insertErr == nil {
+					continue
+				}
+				rowErr := rowError(insertErr)
+				if rowErr == nil {
+					return insertErr
+				}
+				result[freshIdx[j]] = rowErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if !queueJobs {
+			return nil
+		}
+		var ids []string
+		for i, m := range movies {
+			if result[i] == nil {
+				ids = append(ids, m.ID)
+			}
+		}
+		return enqueueJobs(ctx, tx, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RowError is a database rejection of one row's values, such as a title longer
+// than the column allows.
+type RowError struct {
+	Message string
+}
+
+func (e *RowError) Error() string {
+	return e.Message
+}
+
+// rowError classifies an insert error caused by the row's own values as
+// ErrDuplicateTitle or a *RowError; other errors, and nil, yield nil.
+func rowError(err error) error {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return nil
+	}
+	switch myErr.Number {
+	case 1062:
+		return ErrDuplicateTitle
+	case 1264, 1292, 1366, 1406: // out of range, bad date, bad value, too long
+		return &RowError{Message: myErr.Message}
+	}
+	return nil
+}
+
+func insertMovie(ctx context.Context, ext sqlx.ExecerContext, movie *Movie) error {
+	return insertMovies(ctx, ext, []*Movie{movie})
+}
+
+// insertMovies inserts movies with a single multi-row INSERT.
+func insertMovies(ctx context.Context, ext sqlx.ExecerContext, movies []*Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
 	query := `
-		INSERT INTO movies (id, title, release_date, genre, distributor, budget, mpa_rating)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`
-	_, err := s.db.ExecContext(ctx, query,
-		movie.ID, movie.Title, movie.ReleaseDate, movie.Genre,
-		movie.Distributor, movie.Budget, movie.MPARating,
-	)
+		INSERT INTO movies (id, title, release_date, genre, distributor, budget, mpa_rating, field_sources)
+		VALUES ` + strings.TrimSuffix(strings.Repeat(`(?, ?, ?, ?, ?, ?, ?, ?), `, len(movies)), ", ")
+	args := make([]interface{}, 0, 8*len(movies))
+	for _, movie := range movies {
+		args = append(args,
+			movie.ID, movie.Title, movie.ReleaseDate, movie.Genre,
+			movie.Distributor, movie.Budget, movie.MPARating, movie.FieldSources,
+		)
+	}
+	_, err := ext.ExecContext(ctx, query, args...)
 	return err
 }
 
 // GetByTitle retrieves a movie by title.
 func (s *MovieStore) GetByTitle(ctx context.Context, title string) (*Movie, error) {
 	var movie Movie
-	query := `SELECT id, title, release_date, genre, distributor, budget, mpa_rating, created_at, updated_at
-	          FROM movies WHERE title = ?`
+	query := `SELECT ` + movieColumns + ` FROM movies m WHERE m.title = ?`
 	err := s.db.GetContext(ctx, &movie, query, title)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -100,8 +388,7 @@ func (s *MovieStore) GetByTitle(ctx context.Context, title string) (*Movie, erro
 // GetByID retrieves a movie by ID.
 func (s *MovieStore) GetByID(ctx context.Context, id string) (*Movie, error) {
 	var movie Movie
-	query := `SELECT id, title, release_date, genre, distributor, budget, mpa_rating, created_at, updated_at
-	          FROM movies WHERE id = ?`
+	query := `SELECT ` + movieColumns + ` FROM movies m WHERE m.id = ?`
 	err := s.db.GetContext(ctx, &movie, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -112,29 +399,239 @@ func (s *MovieStore) GetByID(ctx context.Context, id string) (*Movie, error) {
 	return &movie, nil
 }
 
-// SetBoxOffice stores box office data for a movie.
-func (s *MovieStore) SetBoxOffice(ctx context.Context, movieID string, bo *BoxOfficeRow) error {
+// LastModified returns when anything shown in a movie's full representation
+// last changed: the movie row, its box office figures, its ratings or its
+// enrichment jobs. The related tables never touch movies.updated_at, so the
+// movie's own timestamp alone would go stale. It returns the zero time for an
+// unknown movie.
+func (s *MovieStore) LastModified(ctx context.Context, movieID string) (time.Time, error) {
+	var modified time.Time
+	query := `SELECT GREATEST(m.updated_at,
+		COALESCE((SELECT b.fetched_at FROM movie_box_office b WHERE b.movie_id = m.id), m.updated_at),
+		COALESCE((SELECT MAX(r.updated_at) FROM movie_ratings r WHERE r.movie_id = m.id), m.updated_at),
+		COALESCE((SELECT MAX(j.updated_at) FROM enrichment_jobs j WHERE j.movie_id = m.id), m.updated_at))
+		FROM movies m WHERE m.id = ?`
+	err := s.db.GetContext(ctx, &modified, query, movieID)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return modified, err
+}
+
+// ListTitles returns the ID, title and release date of every movie, for
+// building in-memory title indexes; other fields are left zero.
+func (s *MovieStore) ListTitles(ctx context.Context) ([]Movie, error) {
+	movies := []Movie{}
+	err := s.db.SelectContext(ctx, &movies, `SELECT m.id, m.title, m.release_date FROM movies m ORDER BY m.id`)
+	if err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// Update replaces the mutable fields of a movie. When expectedUpdatedAt is set the
+// write only happens if the stored row still carries that timestamp. On success
+// movie.CreatedAt and movie.UpdatedAt are refreshed from the database.
+func (s *MovieStore) Update(ctx context.Context, movie *Movie, expectedUpdatedAt *time.Time) error {
+	return s.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		if err := checkVersion(ctx, tx, movie.ID, expectedUpdatedAt); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE movies
+			SET title = ?, release_date = ?, genre = ?, distributor = ?, budget = ?, mpa_rating = ?,
+			    field_sources = ?, updated_at = CURRENT_TIMESTAMP(6)
+			WHERE id = ?
+		`
+		_, err := tx.ExecContext(ctx, query,
+			movie.Title, movie.ReleaseDate, movie.Genre,
+			movie.Distributor, movie.Budget, movie.MPARating, movie.FieldSources, movie.ID,
+		)
+		if err != nil {
+			if isDuplicateKey(err) {
+				return ErrDuplicateTitle
+			}
+			return err
+		}
+
+		return tx.GetContext(ctx, movie, `SELECT `+movieColumns+` FROM movies m WHERE m.id = ?`, movie.ID)
+	})
+}
+
+// Delete removes a movie; box office data and ratings go with it via ON DELETE CASCADE.
+func (s *MovieStore) Delete(ctx context.Context, id string, expectedUpdatedAt *time.Time) error {
+	return s.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		if err := checkVersion(ctx, tx, id, expectedUpdatedAt); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM movies WHERE id = ?`, id)
+		return err
+	})
+}
+
+// checkVersion locks the movie row and verifies it still matches expectedUpdatedAt.
+func checkVersion(ctx context.Context, tx *sqlx.Tx, id string, expectedUpdatedAt *time.Time) error {
+	var current time.Time
+	err := tx.GetContext(ctx, &current, `SELECT updated_at FROM movies WHERE id = ? FOR UPDATE`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	if expectedUpdatedAt != nil && !current.Equal(*expectedUpdatedAt) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+func isDuplicateKey(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == 1062
+}
+
+// CreateEnriched inserts a new movie together with its box office row, if any,
+// in one transaction so the stored movie always matches the enriched response.
+func (s *MovieStore) CreateEnriched(ctx context.Context, movie *Movie, bo *BoxOfficeRow) error {
+	return s.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		if err := insertMovie(ctx, tx, movie); err != nil {
+			return err
+		}
+		if bo == nil {
+			return nil
+		}
+		return setBoxOffice(ctx, tx, movie.ID, bo)
+	})
+}
+
+// ApplyEnrichment locks the movie, lets merge fold upstream data into the current
+// row and persists the merged distributor/budget/mpaRating, their provenance and
+// the returned box office row in one transaction. Running merge under the row
+// lock keeps concurrent user edits from being overwritten with stale values.
+// It returns the movie as stored, or ErrNotFound.
+func (s *MovieStore) ApplyEnrichment(ctx context.Context, movieID string, merge func(current *Movie) *BoxOfficeRow) (*Movie, error) {
+	var movie Movie
+	err := s.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &movie, `SELECT `+movieColumns+` FROM movies m WHERE m.id = ? FOR UPDATE`, movieID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		bo := merge(&movie)
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE movies SET distributor = ?, budget = ?, mpa_rating = ?, field_sources = ? WHERE id = ?`,
+			movie.Distributor, movie.Budget, movie.MPARating, movie.FieldSources, movie.ID)
+		if err != nil {
+			return err
+		}
+
+		if bo != nil {
+			if err := setBoxOffice(ctx, tx, movie.ID, bo); err != nil {
+				return err
+			}
+		}
+
+		return tx.GetContext(ctx, &movie, `SELECT `+movieColumns+` FROM movies m WHERE m.id = ?`, movieID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// setBoxOffice overwrites the current figures and records a snapshot; ext should
+// be a transaction so the two stay consistent.
+func setBoxOffice(ctx context.Context, ext sqlx.ExecerContext, movieID string, bo *BoxOfficeRow) error {
+	args := []interface{}{
+		movieID, bo.Gross, bo.Domestic, bo.International, bo.OpeningWeekendUSA,
+		bo.TheaterCount, bo.WeeksInRelease, bo.Currency, bo.Source, bo.LastReported,
+	}
 	query := `
-		INSERT INTO movie_box_office (movie_id, gross_usd, opening_weekend_usa, currency, source, last_reported)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO movie_box_office (movie_id, ` + boxOfficeColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
-			gross_usd = VALUES(gross_usd),
+			gross = VALUES(gross),
+			domestic = VALUES(domestic),
+			international = VALUES(international),
 			opening_weekend_usa = VALUES(opening_weekend_usa),
+			theater_count = VALUES(theater_count),
+			weeks_in_release = VALUES(weeks_in_release),
 			currency = VALUES(currency),
 			source = VALUES(source),
 			last_reported = VALUES(last_reported),
-			fetched_at = CURRENT_TIMESTAMP(6)
+			fetched_at = CURRENT_TIMESTAMP(6),
+			refresh_failures = 0,
+			refresh_after = NULL
 	`
-	_, err := s.db.ExecContext(ctx, query,
-		movieID, bo.GrossUSD, bo.OpeningWeekendUSA, bo.Currency, bo.Source, bo.LastReported,
-	)
+	if _, err := ext.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	_, err := ext.ExecContext(ctx, `
+		INSERT INTO movie_box_office_snapshots (movie_id, `+boxOfficeColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
 	return err
 }
 
+// ListBoxOfficeHistory returns a movie's box office snapshots reported within
+// [from, to), oldest first. Nil bounds are open.
+func (s *MovieStore) ListBoxOfficeHistory(ctx context.Context, movieID string, from, to *time.Time) ([]BoxOfficeSnapshot, error) {
+	query := `SELECT id, movie_id, ` + boxOfficeColumns + `, fetched_at
+	          FROM movie_box_office_snapshots WHERE movie_id = ?`
+	args := []interface{}{movieID}
+	if from != nil {
+		query += ` AND last_reported >= ?`
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += ` AND last_reported < ?`
+		args = append(args, *to)
+	}
+	query += ` ORDER BY last_reported, id`
+
+	snapshots := []BoxOfficeSnapshot{}
+	if err := s.db.SelectContext(ctx, &snapshots, query, args...); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// ListBoxOfficeHistories returns the full box office history of several movies,
+// oldest first per movie. Movies without snapshots map to an empty slice.
+func (s *MovieStore) ListBoxOfficeHistories(ctx context.Context, movieIDs []string) (map[string][]BoxOfficeSnapshot, error) {
+	result := make(map[string][]BoxOfficeSnapshot, len(movieIDs))
+	for _, id := range movieIDs {
+		result[id] = []BoxOfficeSnapshot{}
+	}
+	if len(movieIDs) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT id, movie_id, `+boxOfficeColumns+`, fetched_at
+	          FROM movie_box_office_snapshots WHERE movie_id IN (?) ORDER BY movie_id, last_reported, id`, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []BoxOfficeSnapshot
+	if err := s.db.SelectContext(ctx, &rows, s.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.MovieID] = append(result[row.MovieID], row)
+	}
+	return result, nil
+}
+
 // GetBoxOffice retrieves box office data for a movie.
 func (s *MovieStore) GetBoxOffice(ctx context.Context, movieID string) (*BoxOfficeRow, error) {
 	var bo BoxOfficeRow
-	query := `SELECT movie_id, gross_usd, opening_weekend_usa, currency, source, last_reported, fetched_at
+	query := `SELECT movie_id, ` + boxOfficeColumns + `, fetched_at
 	          FROM movie_box_office WHERE movie_id = ?`
 	err := s.db.GetContext(ctx, &bo, query, movieID)
 	if err != nil {
@@ -146,110 +643,493 @@ func (s *MovieStore) GetBoxOffice(ctx context.Context, movieID string) (*BoxOffi
 	return &bo, nil
 }
 
-// Cursor represents a pagination cursor.
-type Cursor struct {
-	CreatedAt time.Time `json:"c"`
-	ID        string    `json:"i"`
+// ListStaleBoxOffice returns movies whose box office data was fetched longer
+// than maxAge ago, oldest first, skipping rows still backing off after failed
+// refreshes.
+func (s *MovieStore) ListStaleBoxOffice(ctx context.Context, maxAge time.Duration, limit int) ([]Movie, error) {
+	query := `SELECT ` + movieColumns + `
+	          FROM movie_box_office b
+	          JOIN movies m ON m.id = b.movie_id
+	          WHERE b.fetched_at < CURRENT_TIMESTAMP(6) - INTERVAL ? MICROSECOND
+	            AND (b.refresh_after IS NULL OR b.refresh_after <= CURRENT_TIMESTAMP(6))
+	          ORDER BY b.fetched_at
+	          LIMIT ?`
+	var movies []Movie
+	if err := s.db.SelectContext(ctx, &movies, query, maxAge.Microseconds(), limit); err != nil {
+		return nil, err
+	}
+	return movies, nil
 }
 
-// EncodeCursor encodes a cursor to base64.
-func EncodeCursor(c Cursor) (string, error) {
-	data, err := json.Marshal(c)
-	if err != nil {
-		return "", err
-	}
-	return base64.URLEncoding.EncodeToString(data), nil
+// TouchBoxOffice bumps fetched_at without changing the stored figures.
+func (s *MovieStore) TouchBoxOffice(ctx context.Context, movieID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE movie_box_office
+		SET fetched_at = CURRENT_TIMESTAMP(6), refresh_failures = 0, refresh_after = NULL
+		WHERE movie_id = ?`, movieID)
+	return err
 }
 
-// DecodeCursor decodes a cursor from base64.
-func DecodeCursor(s string) (*Cursor, error) {
-	if s == "" {
-		return nil, nil
+// RecordRefreshFailure keeps a movie out of ListStaleBoxOffice for base times
+// two to the power of its consecutive failures, at most maxDelay, so titles
+// that keep failing stop taking the places of the rest of the catalogue. The
+// stored figures and fetched_at are left alone.
+func (s *MovieStore) RecordRefreshFailure(ctx context.Context, movieID string, base, maxDelay time.Duration) error {
+	// MySQL applies single-table SET assignments left to right, so
+	// refresh_after sees the failure count before the increment.
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE movie_box_office
+		SET refresh_after = CURRENT_TIMESTAMP(6) + INTERVAL LEAST(? * POW(2, LEAST(refresh_failures, 30)), ?) MICROSECOND,
+		    refresh_failures = refresh_failures + 1
+		WHERE movie_id = ?`,
+		base.Microseconds(), maxDelay.Microseconds(), movieID)
+	return err
+}
+
+// GetBoxOffices retrieves box office data for several movies keyed by movie ID.
+// Movies without box office data are absent from the map.
+func (s *MovieStore) GetBoxOffices(ctx context.Context, movieIDs []string) (map[string]*BoxOfficeRow, error) {
+	result := make(map[string]*BoxOfficeRow, len(movieIDs))
+	if len(movieIDs) == 0 {
+		return result, nil
 	}
-	data, err := base64.URLEncoding.DecodeString(s)
+
+	query, args, err := sqlx.In(`SELECT movie_id, `+boxOfficeColumns+`, fetched_at
+	          FROM movie_box_office WHERE movie_id IN (?)`, movieIDs)
 	if err != nil {
-		return nil, fmt.Errorf("invalid cursor: %w", err)
+		return nil, err
 	}
-	var c Cursor
-	if err := json.Unmarshal(data, &c); err != nil {
-		return nil, fmt.Errorf("invalid cursor: %w", err)
+
+	var rows []BoxOfficeRow
+	if err := s.db.SelectContext(ctx, &rows, s.db.Rebind(query), args...); err != nil {
+		return nil, err
 	}
-	return &c, nil
+	for i := range rows {
+		result[rows[i].MovieID] = &rows[i]
+	}
+	return result, nil
 }
 
-// ListFilters represents query filters for listing movies.
+// ListFilters represents query filters for listing movies. Box office ranges
+// compare amounts in their stored currency.
 type ListFilters struct {
-	Query       string
-	Year        *int
-	Genre       string
-	Distributor string
-	Budget      *int64
-	MPARating   string
-	Limit       int
-	Cursor      *Cursor
-}
-
-// List retrieves movies with filters and pagination.
-func (s *MovieStore) List(ctx context.Context, filters ListFilters) ([]Movie, *Cursor, error) {
+	Query              string
+	ReleaseDate        DateRange
+	Genre              ValueSet
+	Distributor        ValueSet
+	Budget             Int64Range
+	MPARating          ValueSet
+	WorldwideGross     Int64Range
+	DomesticGross      Int64Range
+	InternationalGross Int64Range
+	TheaterCount       Int64Range
+	WeeksInRelease     Int64Range
+	// MinAverageRating compares against the rounded average shown in responses.
+	MinAverageRating *float64
+	MinRatingCount   *int64
+	// Sort is one of the Sort* keys (default SortCreatedAt); Desc reverses it.
+	Sort   string
+	Desc   bool
+	Limit  int
+	Cursor *Cursor
+	// Fields limits the movie fields List reads to those named (Field*
+	// constants); the ID is always read. Nil reads every field.
+	Fields []string
+	// IncludeRating attaches the rating aggregate to every returned movie, and
+	// IncludeBoxOfficeHistory every box office snapshot.
+	IncludeRating           bool
+	IncludeBoxOfficeHistory bool
+}
+
+// wantsField reports whether List reads the named field.
+func (f ListFilters) wantsField(name string) bool {
+	return f.Fields == nil || slices.Contains(f.Fields, name)
+}
+
+// selectColumns lists the movies columns List reads, always including the ID
+// and any fields in extra.
+func (f ListFilters) selectColumns(extra ...string) string {
+	if f.Fields == nil {
+		return movieColumns
+	}
+	cols := []string{fieldColumns[FieldID]}
+	for _, name := range append(slices.Clone(f.Fields), extra...) {
+		col, ok := fieldColumns[name]
+		if ok && !slices.Contains(cols, col) {
+			cols = append(cols, col)
+		}
+	}
+	return strings.Join(cols, ", ")
+}
+
+// needsBoxOffice reports whether the filters read movie_box_office columns.
+func (f ListFilters) needsBoxOffice() bool {
+	return f.WorldwideGross.IsSet() || f.DomesticGross.IsSet() || f.InternationalGross.IsSet() ||
+		f.TheaterCount.IsSet() || f.WeeksInRelease.IsSet()
+}
+
+// needsRatings reports whether the filters read rating aggregates.
+func (f ListFilters) needsRatings() bool {
+	return f.MinAverageRating != nil || f.MinRatingCount != nil
+}
+
+// searchMatch scores a row against the search query. MATCH must name exactly
+// the columns of the ft_movies_search FULLTEXT index, so columns made
+// searchable later (a synopsis, cast names) are added to both together.
+const searchMatch = `MATCH(m.title) AGAINST (? IN NATURAL LANGUAGE MODE)`
+
+// fuzzyCandidates caps how many rows the typo-tolerant fallback ranks in Go.
+const fuzzyCandidates = 500
+
+// fuzzyMinSimilarity is the lowest search.Similarity a fallback hit may have.
+const fuzzyMinSimilarity = 0.6
+
+// listRow is a movie together with the value List sorted it by and, for
+// searches, its relevance score.
+type listRow struct {
+	Movie
+	SortKey string          `db:"sort_key"`
+	Score   sql.NullFloat64 `db:"score"`
+}
+
+// Page is one page of List results. Next continues after the last movie and
+// Prev before the first; each is nil at that end of the listing. Fuzzy is set
+// when the movies come from the typo-tolerant search fallback, which has no
+// further pages.
+type Page struct {
+	Movies []Movie
+	Next   *Cursor
+	Prev   *Cursor
+	Fuzzy  bool
+}
+
+// List retrieves movies with filters and keyset pagination in either
+// direction. A search query is matched against the FULLTEXT index; when the
+// first page has no hits, a typo-tolerant fallback ranks near matches instead
+// and returns a single page.
+func (s *MovieStore) List(ctx context.Context, filters ListFilters) (*Page, error) {
 	if filters.Limit <= 0 {
 		filters.Limit = 20
 	}
+	if filters.Sort == "" {
+		filters.Sort = DefaultSort(filters.Query)
+	}
+	key, ok := sortKeys[filters.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", filters.Sort)
+	}
+	if key.search && filters.Query == "" {
+		return nil, errors.New("relevance sort requires a search query")
+	}
 
-	query := `SELECT id, title, release_date, genre, distributor, budget, mpa_rating, created_at, updated_at
-	          FROM movies WHERE 1=1`
-	args := []interface{}{}
+	rows, page, err := s.listPage(ctx, filters, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 && filters.Query != "" && filters.Cursor == nil {
+		if rows, err = s.fuzzySearch(ctx, filters); err != nil {
+			return nil, err
+		}
+		page.Fuzzy = true
+	}
+
+	page.Movies = make([]Movie, len(rows))
+	for i := range rows {
+		page.Movies[i] = rows[i].Movie
+		if rows[i].Score.Valid {
+			score := rows[i].Score.Float64
+			page.Movies[i].Score = &score
+		}
+	}
+	if err := s.hydrate(ctx, page.Movies, filters); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
 
-	if filters.Cursor != nil {
-		query += ` AND (created_at > ? OR (created_at = ? AND id > ?))`
-		args = append(args, filters.Cursor.CreatedAt, filters.Cursor.CreatedAt, filters.Cursor.ID)
+// listPage fetches the rows of one page and the cursors around it. A Before
+// cursor is served by walking the sort order in reverse from it and flipping
+// the rows back.
+func (s *MovieStore) listPage(ctx context.Context, filters ListFilters, key sortKey) ([]listRow, *Page, error) {
+	// keyArgs are the arguments of one occurrence of key.expr.
+	var keyArgs []interface{}
+	if key.search {
+		keyArgs = []interface{}{filters.Query}
 	}
 
+	query := `SELECT ` + filters.selectColumns() + `, CAST(` + key.expr + ` AS CHAR) AS sort_key`
+	args := append([]interface{}{}, keyArgs...)
 	if filters.Query != "" {
-		query += ` AND title LIKE ?`
-		args = append(args, "%"+filters.Query+"%")
+		query += `, ` + searchMatch + ` AS score`
+		args = append(args, filters.Query)
 	}
 
-	if filters.Year != nil {
-		query += ` AND YEAR(release_date) = ?`
-		args = append(args, *filters.Year)
+	from, fromArgs := filters.fromWhere(key.join, searchMatch, filters.Query)
+	query += from
+	args = append(args, fromArgs...)
+
+	backward := filters.Cursor != nil && filters.Cursor.Before
+	cmp, dir := ">", "ASC"
+	if filters.Desc != backward {
+		cmp, dir = "<", "DESC"
 	}
 
-	if filters.Genre != "" {
-		query += ` AND LOWER(genre) = LOWER(?)`
-		args = append(args, filters.Genre)
+	if c := filters.Cursor; c != nil {
+		query += ` AND (` + key.expr + ` ` + cmp + ` ` + key.arg +
+			` OR (` + key.expr + ` = ` + key.arg + ` AND m.id ` + cmp + ` ?))`
+		args = append(args, keyArgs...)
+		args = append(args, c.Value)
+		args = append(args, keyArgs...)
+		args = append(args, c.Value, c.ID)
 	}
 
-	if filters.Distributor != "" {
-		query += ` AND LOWER(distributor) = LOWER(?)`
-		args = append(args, filters.Distributor)
+	query += ` ORDER BY ` + key.expr + ` ` + dir + `, m.id ` + dir + ` LIMIT ?`
+	args = append(args, keyArgs...)
+	args = append(args, filters.Limit+1)
+
+	var rows []listRow
+	if err := s.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, nil, err
 	}
+	rows, page := pageOf(rows, filters)
+	return rows, page, nil
+}
 
-	if filters.Budget != nil {
-		query += ` AND budget <= ?`
-		args = append(args, *filters.Budget)
+// pageOf turns the up to filters.Limit+1 rows a listPage query returned, in
+// query order, into the page's rows in sort order and its cursors.
+func pageOf(rows []listRow, filters ListFilters) ([]listRow, *Page) {
+	backward := filters.Cursor != nil && filters.Cursor.Before
+	more := len(rows) > filters.Limit
+	if more {
+		rows = rows[:filters.Limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
 	}
 
-	if filters.MPARating != "" {
-		query += ` AND mpa_rating = ?`
-		args = append(args, filters.MPARating)
+	page := &Page{}
+	if len(rows) == 0 {
+		return rows, page
+	}
+	at := func(row listRow, before bool) *Cursor {
+		return &Cursor{Sort: filters.Sort, Desc: filters.Desc, Before: before, Value: row.SortKey, ID: row.ID}
 	}
+	first, last := rows[0], rows[len(rows)-1]
+	// Paging backward always starts from a later row, and paging forward from
+	// a cursor from an earlier one.
+	if more || backward {
+		page.Next = at(last, false)
+	}
+	if (more && backward) || (filters.Cursor != nil && !backward) {
+		page.Prev = at(first, true)
+	}
+	return rows, page
+}
 
-	query += ` ORDER BY created_at, id LIMIT ?`
-	args = append(args, filters.Limit+1)
+// exportBatchSize is how many movies Export reads per keyset query.
+const exportBatchSize = 500
 
-	var movies []Movie
-	if err := s.db.SelectContext(ctx, &movies, query, args...); err != nil {
-		return nil, nil, err
+// Export passes every movie matching filters to fn in batches, in the filters'
+// sort order, each with its box office and rating aggregate. Limit, Cursor,
+// Fields and the history expansion are ignored. Batches are read with keyset
+// queries as fn consumes them, so memory stays bounded by one batch and no
+// statement is held open while fn writes. Searches never fall back to fuzzy
+// matching. fn must not keep the slice.
+func (s *MovieStore) Export(ctx context.Context, filters ListFilters, fn func(movies []Movie) error) error {
+	if filters.Sort == "" {
+		filters.Sort = DefaultSort(filters.Query)
+	}
+	key, ok := sortKeys[filters.Sort]
+	if !ok {
+		return fmt.Errorf("unknown sort %q", filters.Sort)
+	}
+	if key.search && filters.Query == "" {
+		return errors.New("relevance sort requires a search query")
 	}
+	filters.Limit, filters.Cursor, filters.Fields = exportBatchSize, nil, nil
+	filters.IncludeRating, filters.IncludeBoxOfficeHistory = true, false
 
-	var nextCursor *Cursor
-	if len(movies) > filters.Limit {
-		last := movies[filters.Limit-1]
-		nextCursor = &Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
-		movies = movies[:filters.Limit]
+	movies := make([]Movie, 0, exportBatchSize)
+	for {
+		rows, page, err := s.listPage(ctx, filters, key)
+		if err != nil {
+			return err
+		}
+		movies = movies[:0]
+		for i := range rows {
+			movie := rows[i].Movie
+			if rows[i].Score.Valid {
+				score := rows[i].Score.Float64
+				movie.Score = &score
+			}
+			movies = append(movies, movie)
+		}
+		if err := s.hydrate(ctx, movies, filters); err != nil {
+			return err
+		}
+		if len(movies) > 0 {
+			if err := fn(movies); err != nil {
+				return err
+			}
+		}
+		if page.Next == nil {
+			return nil
+		}
+		filters.Cursor = page.Next
+	}
+}
+
+// fuzzySearch finds titles close to the query despite typos. Candidates share a
+// word prefix with the query, through the FULLTEXT index in boolean mode or a
+// title prefix, and are ranked in Go by search.Similarity, which becomes their
+// score. The requested sort does not apply; results are best match first.
+func (s *MovieStore) fuzzySearch(ctx context.Context, filters ListFilters) ([]listRow, error) {
+	terms := search.Terms(filters.Query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = truncateRunes(term, 3) + "*"
+	}
+
+	cond := `(MATCH(m.title) AGAINST (? IN BOOLEAN MODE) OR m.title LIKE ?)`
+	from, args := filters.fromWhere("", cond, strings.Join(prefixes, " "), truncateRunes(terms[0], 3)+"%")
+	query := `SELECT ` + filters.selectColumns(FieldTitle) + `, '' AS sort_key` + from + ` LIMIT ?`
+	args = append(args, fuzzyCandidates)
+
+	var candidates []listRow
+	if err := s.db.SelectContext(ctx, &candidates, query, args...); err != nil {
+		return nil, err
+	}
+
+	rows := candidates[:0]
+	for _, row := range candidates {
+		score := search.Similarity(filters.Query, row.Title)
+		if score < fuzzyMinSimilarity {
+			continue
+		}
+		row.Score = sql.NullFloat64{Float64: score, Valid: true}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Score.Float64 != rows[j].Score.Float64 {
+			return rows[i].Score.Float64 > rows[j].Score.Float64
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	if len(rows) > filters.Limit {
+		rows = rows[:filters.Limit]
+	}
+	return rows, nil
+}
+
+// fromWhere renders the FROM and WHERE clauses selecting the movies that match
+// the filters, ignoring sort and cursor; the WHERE clause can be extended with
+// further "AND" conditions. join is an extra join the caller needs. When the
+// filters carry a search query, searchCond (with searchArgs) restricts the rows
+// to hits.
+func (f ListFilters) fromWhere(join, searchCond string, searchArgs ...interface{}) (string, []interface{}) {
+	query := ` FROM movies m`
+	if join == joinBoxOffice || f.needsBoxOffice() {
+		query += joinBoxOffice
+	}
+	if join == joinRatings || f.needsRatings() {
+		query += joinRatings
+	}
+	query += ` WHERE 1=1`
+	args := []interface{}{}
+
+	if f.Query != "" {
+		query += ` AND ` + searchCond
+		args = append(args, searchArgs...)
+	}
+
+	query, args = appendDateRange(query, args, "m.release_date", f.ReleaseDate)
+
+	query, args = appendValueSet(query, args, "m.genre", f.Genre)
+	query, args = appendValueSet(query, args, "m.distributor", f.Distributor)
+
+	query, args = appendRange(query, args, "m.budget", f.Budget)
+
+	query, args = appendValueSet(query, args, "m.mpa_rating", f.MPARating)
+
+	query, args = appendRange(query, args, "b.gross", f.WorldwideGross)
+	query, args = appendRange(query, args, "b.domestic", f.DomesticGross)
+	query, args = appendRange(query, args, "b.international", f.InternationalGross)
+	query, args = appendRange(query, args, "b.theater_count", f.TheaterCount)
+	query, args = appendRange(query, args, "b.weeks_in_release", f.WeeksInRelease)
+
+	if f.MinAverageRating != nil {
+		query += ` AND ROUND(COALESCE(r.average, 0), 1) >= ?`
+		args = append(args, *f.MinAverageRating)
+	}
+	if f.MinRatingCount != nil {
+		query += ` AND COALESCE(r.count, 0) >= ?`
+		args = append(args, *f.MinRatingCount)
+	}
+	return query, args
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		r = r[:n]
+	}
+	return string(r)
+}
+
+// hydrate attaches the related data filters ask for to a page of movies, using
+// one IN query per table instead of one query per movie: box office rows unless
+// the boxOffice field is left out, and rating aggregates and box office history
+// when requested.
+func (s *MovieStore) hydrate(ctx context.Context, movies []Movie, filters ListFilters) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(movies))
+	for i := range movies {
+		ids[i] = movies[i].ID
+	}
+
+	var err error
+	var boxOffice map[string]*BoxOfficeRow
+	if filters.wantsField(FieldBoxOffice) {
+		if boxOffice, err = s.GetBoxOffices(ctx, ids); err != nil {
+			return err
+		}
+	}
+
+	var aggregates map[string]*RatingAggregate
+	if filters.IncludeRating {
+		if aggregates, err = loadAggregates(ctx, s.db, ids); err != nil {
+			return err
+		}
+	}
+
+	var histories map[string][]BoxOfficeSnapshot
+	if filters.IncludeBoxOfficeHistory {
+		if histories, err = s.ListBoxOfficeHistories(ctx, ids); err != nil {
+			return err
+		}
 	}
 
-	return movies, nextCursor, nil
+	for i := range movies {
+		if bo, ok := boxOffice[movies[i].ID]; ok {
+			movies[i].BoxOffice = bo.ToBoxOffice()
+		}
+		if filters.IncludeRating {
+			movies[i].Rating = aggregates[movies[i].ID]
+		}
+		if filters.IncludeBoxOfficeHistory {
+			movies[i].BoxOfficeHistory = histories[movies[i].ID]
+		}
+	}
+	return nil
 }
 
 // RatingStore handles rating persistence.
@@ -287,6 +1167,38 @@ func (s *RatingStore) GetAggregate(ctx context.Context, movieID string) (*Rating
 	return &agg, nil
 }
 
+// loadAggregates computes rating aggregates for several movies keyed by movie
+// ID. Every requested ID is present; movies without ratings get a zero
+// aggregate.
+func loadAggregates(ctx context.Context, db *DB, movieIDs []string) (map[string]*RatingAggregate, error) {
+	result := make(map[string]*RatingAggregate, len(movieIDs))
+	for _, id := range movieIDs {
+		result[id] = &RatingAggregate{}
+	}
+	if len(movieIDs) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT movie_id, COALESCE(ROUND(AVG(rating), 1), 0) as average, COUNT(*) as count
+	          FROM movie_ratings WHERE movie_id IN (?) GROUP BY movie_id`, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		MovieID string `db:"movie_id"`
+		RatingAggregate
+	}
+	if err := db.SelectContext(ctx, &rows, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		agg := row.RatingAggregate
+		result[row.MovieID] = &agg
+	}
+	return result, nil
+}
+
 // Exists checks if a rating exists for a movie and rater.
 func (s *RatingStore) Exists(ctx context.Context, movieID, raterID string) (bool, error) {
 	var count int
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// keysetQuery mimics the listPage query over an in-memory table: rows strictly
+// past the cursor in the direction being walked, at most Limit+1 of them.
+func keysetQuery(table []listRow, filters ListFilters) []listRow {
+	less := func(a, b listRow) bool {
+		if a.SortKey != b.SortKey {
+			return a.SortKey < b.SortKey
+		}
+		return a.ID < b.ID
+	}
+	c := filters.Cursor
+	desc := filters.Desc != (c != nil && c.Before)
+
+	ordered := append([]listRow(nil), table...)
+	sort.Slice(ordered, func(i, j int) bool {
+		if desc {
+			return less(ordered[j], ordered[i])
+		}
+		return less(ordered[i], ordered[j])
+	})
+
+	var rows []listRow
+	for _, row := range ordered {
+		if c != nil {
+			anchor := listRow{SortKey: c.Value}
+			anchor.ID = c.ID
+			if (!desc && !less(anchor, row)) || (desc && !less(row, anchor)) {
+				continue
+			}
+		}
+		rows = append(rows, row)
+		if len(rows) == filters.Limit+1 {
+			break
+		}
+	}
+	return rows
+}
+
+func ids(rows []listRow) []string {
+	out := make([]string, len(rows))
+	for i := range rows {
+		out[i] = rows[i].ID
+	}
+	return out
+}
+
+func TestPageOfRoundTrip(t *testing.T) {
+	// Sort keys repeat so the ID tie-breaker is exercised.
+	keys := []string{"a", "b", "b", "c", "d", "d", "d", "e"}
+	table := make([]listRow, len(keys))
+	for i, k := range keys {
+		table[i].ID = fmt.Sprintf("id%02d", i)
+		table[i].SortKey = k
+	}
+
+	for _, desc := range []bool{false, true} {
+		for _, limit := range []int{1, 2, 3, 4, 8, 10} {
+			t.Run(fmt.Sprintf("desc=%v/limit=%d", desc, limit), func(t *testing.T) {
+				base := ListFilters{Sort: SortTitle, Desc: desc, Limit: limit}
+
+				// Walk forward from the start, following Next.
+				var forward [][]string
+				var last *Page
+				filters := base
+				for {
+					rows, page := pageOf(keysetQuery(table, filters), filters)
+					if len(forward) == 0 && page.Prev != nil {
+						t.Errorf("first page has a prev cursor")
+					}
+					forward = append(forward, ids(rows))
+					last = page
+					if page.Next == nil {
+						break
+					}
+					if len(forward) > len(table) {
+						t.Fatal("forward walk does not terminate")
+					}
+					filters.Cursor = page.Next
+				}
+
+				var all []string
+				for _, p := range forward {
+					all = append(all, p...)
+				}
+				want := ids(keysetQuery(table, ListFilters{Desc: desc, Limit: len(table)}))
+				if !reflect.DeepEqual(all, want) {
+					t.Fatalf("forward walk = %v, want %v", all, want)
+				}
+
+				// Walk back from the last page, following Prev; it must
+				// revisit the same pages, and Next must lead forward again.
+				cursor := last.Prev
+				for i := len(forward) - 2; i >= 0; i-- {
+					if cursor == nil {
+						t.Fatalf("page %d has no prev cursor", i+1)
+					}
+					filters := base
+					filters.Cursor = cursor
+					rows, page := pageOf(keysetQuery(table, filters), filters)
+					if got := ids(rows); !reflect.DeepEqual(got, forward[i]) {
+						t.Fatalf("backward page %d = %v, want %v", i, got, forward[i])
+					}
+					if page.Next == nil {
+						t.Fatalf("backward page %d has no next cursor", i)
+					}
+					next := base
+					next.Cursor = page.Next
+					nextRows, _ := pageOf(keysetQuery(table, next), next)
+					if got := ids(nextRows); !reflect.DeepEqual(got, forward[i+1]) {
+						t.Errorf("next of backward page %d = %v, want %v", i, got, forward[i+1])
+					}
+					cursor = page.Prev
+				}
+				if cursor != nil {
+					t.Errorf("first page reached backward still has a prev cursor")
+				}
+			})
+		}
+	}
+}
+
+func TestPageOfEmpty(t *testing.T) {
+	filters := ListFilters{Sort: SortTitle, Limit: 5, Cursor: &Cursor{Sort: SortTitle, Value: "z", ID: "id"}}
+	rows, page := pageOf(nil, filters)
+	if len(rows) != 0 || page.Next != nil || page.Prev != nil {
+		t.Errorf("pageOf(nil) = %v, %+v, want no rows and no cursors", rows, page)
+	}
+}
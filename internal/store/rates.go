@@ -0,0 +1,217 @@
+package store
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// BaseCurrency is the currency exchange rates are quoted against.
+const BaseCurrency = "USD"
+
+// rateDateLayout formats rate dates in API responses.
+const rateDateLayout = "2006-01-02"
+
+// ExchangeRate is how many units of Currency one US dollar bought on RateDate.
+type ExchangeRate struct {
+	Currency  string    `db:"currency"`
+	RateDate  time.Time `db:"rate_date"`
+	Rate      float64   `db:"rate"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Date returns RateDate formatted as YYYY-MM-DD.
+func (r ExchangeRate) Date() string {
+	return r.RateDate.Format(rateDateLayout)
+}
+
+// Conversion describes how amounts were converted for a response.
+type Conversion struct {
+	From     string  `json:"from"`
+	Rate     float64 `json:"rate"`
+	RateDate string  `json:"rateDate"`
+}
+
+// ExchangeRateStore manages the exchange_rates table.
+type ExchangeRateStore struct {
+	db *DB
+}
+
+// NewExchangeRateStore creates an ExchangeRateStore.
+func NewExchangeRateStore(db *DB) *ExchangeRateStore {
+	return &ExchangeRateStore{db: db}
+}
+
+// Upsert inserts or replaces the rate for a currency and date.
+func (s *ExchangeRateStore) Upsert(ctx context.Context, rate *ExchangeRate) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO exchange_rates (currency, rate_date, rate) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE rate = VALUES(rate)`,
+		rate.Currency, rate.Date(), rate.Rate)
+	return err
+}
+
+// Delete removes the rate for a currency and date; it returns ErrNotFound when
+// there is none.
+func (s *ExchangeRateStore) Delete(ctx context.Context, currency string, date time.Time) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM exchange_rates WHERE currency = ? AND rate_date = ?`,
+		currency, date.Format(rateDateLayout))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns stored rates ordered by currency and date, optionally for one currency.
+func (s *ExchangeRateStore) List(ctx context.Context, currency string) ([]ExchangeRate, error) {
+	query := `SELECT currency, rate_date, rate, updated_at FROM exchange_rates`
+	var args []interface{}
+	if currency != "" {
+		query += ` WHERE currency = ?`
+		args = append(args, currency)
+	}
+	query += ` ORDER BY currency, rate_date`
+
+	rates := []ExchangeRate{}
+	if err := s.db.SelectContext(ctx, &rates, query, args...); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Table loads every rate for the given currencies into a RateTable.
+func (s *ExchangeRateStore) Table(ctx context.Context, currencies []string) (*RateTable, error) {
+	table := &RateTable{rates: make(map[string][]ExchangeRate)}
+	if len(currencies) == 0 {
+		return table, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT currency, rate_date, rate, updated_at FROM exchange_rates
+		WHERE currency IN (?) ORDER BY currency, rate_date`, currencies)
+	if err != nil {
+		return nil, err
+	}
+	var rates []ExchangeRate
+	if err := s.db.SelectContext(ctx, &rates, s.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, r := range rates {
+		table.rates[r.Currency] = append(table.rates[r.Currency], r)
+	}
+	return table, nil
+}
+
+// RateTable converts amounts between currencies using loaded rates.
+type RateTable struct {
+	rates map[string][]ExchangeRate // per currency, ordered by date
+}
+
+// Has reports whether amounts can be converted to or from currency.
+func (t *RateTable) Has(currency string) bool {
+	return currency == BaseCurrency || len(t.rates[currency]) > 0
+}
+
+// lookup returns the latest rate on or before on, falling back to the earliest
+// rate when all of them are later.
+func (t *RateTable) lookup(currency string, on time.Time) (ExchangeRate, bool) {
+	rates := t.rates[currency]
+	if len(rates) == 0 {
+		return ExchangeRate{}, false
+	}
+	day := on.UTC().Truncate(24 * time.Hour)
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].RateDate.After(day) })
+	if i == 0 {
+		return rates[0], true
+	}
+	return rates[i-1], true
+}
+
+// conversion returns the factor from one currency to another for amounts
+// reported on the given date. The rate date is the older of the two rates
+// involved; ok is false when either currency has no rates.
+func (t *RateTable) conversion(from, to string, on time.Time) (*Conversion, bool) {
+	rate := 1.0
+	var rateDate time.Time
+	use := func(r ExchangeRate) {
+		if rateDate.IsZero() || r.RateDate.Before(rateDate) {
+			rateDate = r.RateDate
+		}
+	}
+
+	if from != BaseCurrency {
+		r, ok := t.lookup(from, on)
+		if !ok {
+			return nil, false
+		}
+		rate /= r.Rate
+		use(r)
+	}
+	if to != BaseCurrency {
+		r, ok := t.lookup(to, on)
+		if !ok {
+			return nil, false
+		}
+		rate *= r.Rate
+		use(r)
+	}
+	return &Conversion{From: from, Rate: rate, RateDate: rateDate.Format(rateDateLayout)}, true
+}
+
+// ConvertBoxOffice converts bo into currency in place. Amounts already in that
+// currency, or in a currency without rates, are left unchanged.
+func (t *RateTable) ConvertBoxOffice(bo *BoxOffice, currency string) {
+	if bo == nil || bo.Currency == currency {
+		return
+	}
+	conv, ok := t.conversion(bo.Currency, currency, bo.LastUpdated)
+	if !ok {
+		return
+	}
+	bo.Revenue.Worldwide = convertAmount(bo.Revenue.Worldwide, conv.Rate)
+	bo.Revenue.Domestic = convertOptional(bo.Revenue.Domestic, conv.Rate)
+	bo.Revenue.International = convertOptional(bo.Revenue.International, conv.Rate)
+	bo.Revenue.OpeningWeekendUSA = convertOptional(bo.Revenue.OpeningWeekendUSA, conv.Rate)
+	bo.Currency = currency
+	bo.Conversion = conv
+}
+
+// ConvertSnapshot converts a history snapshot into currency in place, using
+// the rate that applied when the figures were reported.
+func (t *RateTable) ConvertSnapshot(snap *BoxOfficeSnapshot, currency string) {
+	if snap.Currency == currency {
+		return
+	}
+	conv, ok := t.conversion(snap.Currency, currency, snap.LastReported)
+	if !ok {
+		return
+	}
+	snap.Gross = convertAmount(snap.Gross, conv.Rate)
+	snap.Domestic = convertOptional(snap.Domestic, conv.Rate)
+	snap.International = convertOptional(snap.International, conv.Rate)
+	snap.OpeningWeekendUSA = convertOptional(snap.OpeningWeekendUSA, conv.Rate)
+	snap.Currency = currency
+	snap.Conversion = conv
+}
+
+func convertAmount(amount int64, rate float64) int64 {
+	return int64(math.Round(float64(amount) * rate))
+}
+
+func convertOptional(amount *int64, rate float64) *int64 {
+	if amount == nil {
+		return nil
+	}
+	v := convertAmount(*amount, rate)
+	return &v
+}
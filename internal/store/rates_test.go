@@ -0,0 +1,126 @@
+package store
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(rateDateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func testRateTable() *RateTable {
+	return &RateTable{rates: map[string][]ExchangeRate{
+		"EUR": {
+			{Currency: "EUR", RateDate: day("2024-01-01"), Rate: 0.9},
+			{Currency: "EUR", RateDate: day("2024-03-01"), Rate: 0.8},
+		},
+		"GBP": {
+			{Currency: "GBP", RateDate: day("2024-02-01"), Rate: 0.75},
+		},
+	}}
+}
+
+func TestRateTableConversion(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		on       time.Time
+		rate     float64
+		rateDate string
+		ok       bool
+	}{
+		{"latest rate on or before", "USD", "EUR", day("2024-02-15"), 0.9, "2024-01-01", true},
+		{"rate from the same day", "USD", "EUR", day("2024-03-01").Add(15 * time.Hour), 0.8, "2024-03-01", true},
+		{"earliest rate when all are later", "USD", "EUR", day("2023-06-01"), 0.9, "2024-01-01", true},
+		{"day is taken in UTC", "USD", "EUR", time.Date(2024, 3, 1, 1, 0, 0, 0, time.FixedZone("UTC+5", 5*3600)), 0.9, "2024-01-01", true},
+		{"to the base currency", "EUR", "USD", day("2024-04-01"), 1.25, "2024-03-01", true},
+		{"cross rate uses the older date", "EUR", "GBP", day("2024-03-15"), 0.9375, "2024-02-01", true},
+		{"cross rate the other way", "GBP", "EUR", day("2024-03-15"), 0.8 / 0.75, "2024-02-01", true},
+		{"unknown target", "USD", "JPY", day("2024-03-15"), 0, "", false},
+		{"unknown source", "JPY", "USD", day("2024-03-15"), 0, "", false},
+	}
+	table := testRateTable()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv, ok := table.conversion(tt.from, tt.to, tt.on)
+			if ok != tt.ok {
+				t.Fatalf("conversion ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(conv.Rate-tt.rate) > 1e-9 {
+				t.Errorf("rate = %v, want %v", conv.Rate, tt.rate)
+			}
+			if conv.RateDate != tt.rateDate {
+				t.Errorf("rateDate = %s, want %s", conv.RateDate, tt.rateDate)
+			}
+			if conv.From != tt.from {
+				t.Errorf("from = %s, want %s", conv.From, tt.from)
+			}
+		})
+	}
+}
+
+func TestRateTableHas(t *testing.T) {
+	table := testRateTable()
+	for currency, want := range map[string]bool{"USD": true, "EUR": true, "GBP": true, "JPY": false} {
+		if got := table.Has(currency); got != want {
+			t.Errorf("Has(%s) = %v, want %v", currency, got, want)
+		}
+	}
+}
+
+func TestConvertBoxOffice(t *testing.T) {
+	int64p := func(n int64) *int64 { return &n }
+	newBoxOffice := func(currency string) *BoxOffice {
+		bo := &BoxOffice{Currency: currency, LastUpdated: day("2024-02-15")}
+		bo.Revenue.Worldwide = 1000
+		bo.Revenue.Domestic = int64p(333)
+		return bo
+	}
+
+	tests := []struct {
+		name      string
+		from, to  string
+		worldwide int64
+		domestic  int64
+		converted bool
+	}{
+		{"converts and rounds", "USD", "EUR", 900, 300, true},
+		{"same currency", "EUR", "EUR", 1000, 333, false},
+		{"no rates", "USD", "JPY", 1000, 333, false},
+	}
+	table := testRateTable()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bo := newBoxOffice(tt.from)
+			table.ConvertBoxOffice(bo, tt.to)
+			if bo.Revenue.Worldwide != tt.worldwide || *bo.Revenue.Domestic != tt.domestic {
+				t.Errorf("revenue = %d/%d, want %d/%d", bo.Revenue.Worldwide, *bo.Revenue.Domestic, tt.worldwide, tt.domestic)
+			}
+			if bo.Revenue.International != nil {
+				t.Errorf("missing international gross became %d", *bo.Revenue.International)
+			}
+			if !tt.converted {
+				if bo.Currency != tt.from || bo.Conversion != nil {
+					t.Errorf("currency = %s, conversion = %+v, want unchanged", bo.Currency, bo.Conversion)
+				}
+				return
+			}
+			want := &Conversion{From: tt.from, Rate: 0.9, RateDate: "2024-01-01"}
+			if bo.Currency != tt.to || !reflect.DeepEqual(bo.Conversion, want) {
+				t.Errorf("currency = %s, conversion = %+v, want %s, %+v", bo.Currency, bo.Conversion, tt.to, want)
+			}
+		})
+	}
+
+	table.ConvertBoxOffice(nil, "EUR") // must not panic
+}
@@ -0,0 +1,74 @@
+package store
+
+// Sort keys accepted by MovieStore.List.
+const (
+	SortCreatedAt          = "createdAt"
+	SortTitle              = "title"
+	SortReleaseDate        = "releaseDate"
+	SortBudget             = "budget"
+	SortWorldwideGross     = "worldwideGross"
+	SortDomesticGross      = "domesticGross"
+	SortInternationalGross = "internationalGross"
+	SortTheaterCount       = "theaterCount"
+	SortWeeksInRelease     = "weeksInRelease"
+	SortAverageRating      = "averageRating"
+	SortRatingCount        = "ratingCount"
+	SortRelevance          = "relevance"
+)
+
+// Joins a sort key or filter may need in List.
+const (
+	joinBoxOffice = ` LEFT JOIN movie_box_office b ON b.movie_id = m.id`
+	joinRatings   = ` LEFT JOIN (
+		SELECT movie_id, AVG(rating) AS average, COUNT(*) AS count
+		FROM movie_ratings GROUP BY movie_id
+	) r ON r.movie_id = m.id`
+)
+
+// sortKey describes how List orders and pages by one key. expr must never be
+// NULL, otherwise keyset comparisons would skip rows, so nullable columns are
+// coalesced to a value below any real one. Cursor values travel as the text
+// MySQL renders for expr and arg converts them back for comparison.
+type sortKey struct {
+	expr   string // SQL expression ordered on
+	arg    string // placeholder comparing a cursor value against expr
+	join   string // join expr reads from, if any
+	search bool   // expr takes the search query as its only argument
+	desc   bool   // default direction is descending
+}
+
+var sortKeys = map[string]sortKey{
+	SortCreatedAt:          {expr: "m.created_at", arg: "?"},
+	SortTitle:              {expr: "m.title", arg: "?"},
+	SortReleaseDate:        {expr: "m.release_date", arg: "?"},
+	SortBudget:             {expr: "COALESCE(m.budget, -1)", arg: "CAST(? AS SIGNED)"},
+	SortWorldwideGross:     {expr: "COALESCE(b.gross, -1)", arg: "CAST(? AS SIGNED)", join: joinBoxOffice},
+	SortDomesticGross:      {expr: "COALESCE(b.domestic, -1)", arg: "CAST(? AS SIGNED)", join: joinBoxOffice},
+	SortInternationalGross: {expr: "COALESCE(b.international, -1)", arg: "CAST(? AS SIGNED)", join: joinBoxOffice},
+	SortTheaterCount:       {expr: "COALESCE(b.theater_count, -1)", arg: "CAST(? AS SIGNED)", join: joinBoxOffice},
+	SortWeeksInRelease:     {expr: "COALESCE(b.weeks_in_release, -1)", arg: "CAST(? AS SIGNED)", join: joinBoxOffice},
+	SortAverageRating:      {expr: "COALESCE(r.average, -1)", arg: "CAST(? AS DECIMAL(20,10))", join: joinRatings},
+	SortRatingCount:        {expr: "COALESCE(r.count, 0)", arg: "CAST(? AS SIGNED)", join: joinRatings},
+	// Scores are fixed to six decimals so they survive the trip through a cursor.
+	SortRelevance: {expr: "CAST(" + searchMatch + " AS DECIMAL(20,6))", arg: "CAST(? AS DECIMAL(20,6))", search: true, desc: true},
+}
+
+// DefaultSort is the sort List uses when none is given: relevance for searches,
+// creation order otherwise.
+func DefaultSort(query string) string {
+	if query != "" {
+		return SortRelevance
+	}
+	return SortCreatedAt
+}
+
+// DefaultDesc reports whether sort runs descending unless told otherwise.
+func DefaultDesc(sort string) bool {
+	return sortKeys[sort].desc
+}
+
+// ValidSort reports whether name is a sort key List understands.
+func ValidSort(name string) bool {
+	_, ok := sortKeys[name]
+	return ok
+}